@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	"github.com/ConductorOne/github-workflows/internal/registry"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run is main with an exit code, so deferred cleanup such as closing the
+// progress file happens before the process exits.
+func run() int {
+	var (
+		root          string
+		registryURL   string
+		token         string
+		workflowRunID string
+		gitBaseURL    string
+		progressPath  string
+		rate          float64
+		dryRun        bool
+	)
+	flag.StringVar(&root, "root", "releases", "Directory laid out as {org}/{repo}/{tag}/manifest.json (e.g. an S3 sync of releases/)")
+	flag.StringVar(&registryURL, "registry-url", "", "Registry API base URL (required unless -dry-run)")
+	flag.StringVar(&token, "token", "", "Bearer token (or set REGISTRY_API_TOKEN env var)")
+	flag.StringVar(&workflowRunID, "workflow-run-id", os.Getenv("GITHUB_RUN_ID"), "ID of the run performing the backfill, sent as each release's workflowRunId (default $GITHUB_RUN_ID; required unless -dry-run)")
+	flag.StringVar(&gitBaseURL, "git-base-url", "https://github.com", "Base URL of {org}/{repo} git remotes; manifests without revisions take their commit from the release tag there (empty disables the lookup)")
+	flag.StringVar(&progressPath, "progress", "backfill-progress.txt", "File recording releases already submitted; re-running skips them")
+	flag.Float64Var(&rate, "rate", 2, "Maximum requests per second")
+	flag.BoolVar(&dryRun, "dry-run", false, "List the releases that would be submitted without contacting the registry")
+	retry := registry.DefaultRetryPolicy
+	flag.IntVar(&retry.MaxAttempts, "max-attempts", retry.MaxAttempts, "Total attempts per release on 429, 5xx and network errors")
+	flag.Parse()
+
+	if rate <= 0 {
+		fmt.Fprintf(os.Stderr, "backfill-releases: error: rate must be positive\n")
+		return 1
+	}
+	if token == "" {
+		token = os.Getenv("REGISTRY_API_TOKEN")
+	}
+	if !dryRun && (registryURL == "" || token == "") {
+		fmt.Fprintf(os.Stderr, "backfill-releases: error: registry-url and a bearer token (-token or REGISTRY_API_TOKEN) are required\n")
+		return 1
+	}
+	if !dryRun && workflowRunID == "" {
+		fmt.Fprintf(os.Stderr, "backfill-releases: error: workflow-run-id (or GITHUB_RUN_ID) is required\n")
+		return 1
+	}
+
+	b := &backfiller{
+		interval:      time.Duration(float64(time.Second) / rate),
+		out:           os.Stdout,
+		dryRun:        dryRun,
+		workflowRunID: workflowRunID,
+	}
+	if gitBaseURL != "" {
+		b.tags = &gitTags{BaseURL: gitBaseURL}
+	}
+	if !dryRun {
+		endpoint, err := registry.EndpointURL(registryURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backfill-releases: error: %v\n", err)
+			return 1
+		}
+		b.client = &registry.Client{
+			Endpoint:   endpoint,
+			Token:      token,
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+			Retry:      retry,
+			Logf: func(format string, args ...any) {
+				fmt.Fprintf(os.Stderr, "backfill-releases: warning: "+format+"\n", args...)
+			},
+		}
+		progress, err := openProgress(progressPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backfill-releases: error: %v\n", err)
+			return 1
+		}
+		defer progress.Close()
+		b.progress = progress
+	}
+
+	summary, err := b.run(context.Background(), root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill-releases: error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Recorded: %d\nAlready present: %d\nSkipped (in progress file): %d\nSkipped (no commit): %d\nFailed: %d\n",
+		summary.Recorded, summary.AlreadyPresent, summary.Skipped, summary.NoCommit, summary.Failed)
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// release is one manifest found under the root directory.
+type release struct {
+	Org  string
+	Repo string
+	Tag  string
+	Path string
+}
+
+// Key identifies the release in the progress file.
+func (r release) Key() string {
+	return r.Org + "/" + r.Repo + "/" + r.Tag
+}
+
+// summary counts the outcome of each release in a run.
+type summary struct {
+	Recorded       int
+	AlreadyPresent int
+	Skipped        int
+	// NoCommit counts releases whose manifest records no commit to send.
+	NoCommit int
+	Failed   int
+}
+
+// backfiller submits every release under a root directory to the registry.
+type backfiller struct {
+	client   *registry.Client
+	progress *progress
+	// interval is the minimum time between requests.
+	interval time.Duration
+	out      io.Writer
+	dryRun   bool
+	// workflowRunID identifies the backfill run in each request.
+	workflowRunID string
+	// tags finds the commit of a release tag for manifests that record no
+	// revision; when nil such releases are skipped.
+	tags tagResolver
+}
+
+func (b *backfiller) run(ctx context.Context, root string) (*summary, error) {
+	releases, err := findReleases(root)
+	if err != nil {
+		return nil, err
+	}
+	s := &summary{}
+	var last time.Time
+	for _, r := range releases {
+		if b.progress != nil && b.progress.Done(r.Key()) {
+			s.Skipped++
+			continue
+		}
+
+		req, err := buildRequest(ctx, r, b.workflowRunID, b.tags)
+		if errors.Is(err, errNoCommit) {
+			fmt.Fprintf(b.out, "⚠️ %s: skipped, %v\n", r.Key(), err)
+			s.NoCommit++
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(b.out, "❌ %s: %v\n", r.Key(), err)
+			s.Failed++
+			continue
+		}
+		if b.dryRun {
+			fmt.Fprintf(b.out, "• %s (commit %s, released %s, %d assets, %d images)\n", r.Key(), req.CommitSha, orUnknown(req.ReleasedAt), len(req.Assets), len(req.Images))
+			continue
+		}
+
+		if wait := b.interval - time.Since(last); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return s, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		last = time.Now()
+
+		status, err := b.submit(ctx, req)
+		switch {
+		case err != nil:
+			fmt.Fprintf(b.out, "❌ %s: %v\n", r.Key(), err)
+			s.Failed++
+			continue
+		case status == http.StatusConflict:
+			// 409 = already recorded, same as record-release
+			fmt.Fprintf(b.out, "✓ %s: already exists\n", r.Key())
+			s.AlreadyPresent++
+		default:
+			fmt.Fprintf(b.out, "✅ %s: recorded\n", r.Key())
+			s.Recorded++
+		}
+		if b.progress != nil {
+			if err := b.progress.MarkDone(r.Key()); err != nil {
+				return s, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// submit posts req and returns the status code for 200 and 409; any other
+// outcome is an error.
+func (b *backfiller) submit(ctx context.Context, req *registry.RecordReleaseRequest) (int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling request body: %w", err)
+	}
+	resp, err := b.client.Post(ctx, body, registry.IdempotencyKey(req.Org, req.Name, req.Version, req.CommitSha))
+	if err != nil {
+		return 0, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusConflict:
+		return resp.StatusCode, nil
+	default:
+		return 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(resp.Body)))
+	}
+}
+
+// findReleases returns every {org}/{repo}/{tag}/manifest.json under root,
+// sorted by path.
+func findReleases(root string) ([]release, error) {
+	var releases []release
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "manifest.json" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 4 {
+			return nil
+		}
+		releases = append(releases, release{Org: parts[0], Repo: parts[1], Tag: parts[2], Path: path})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", root, err)
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Path < releases[j].Path })
+	return releases, nil
+}
+
+// errNoCommit is returned by buildRequest when no commit can be found for a
+// release.
+var errNoCommit = errors.New("no commit found")
+
+// buildRequest reads the manifest for r and builds the registry request
+// record-release would have sent. The commit is taken from the build info and
+// image revisions recorded in the manifest or, for releases that predate
+// them, from the release tag via tags. workflowRunID identifies the backfill
+// run, since the original run is not recorded.
+func buildRequest(ctx context.Context, r release, workflowRunID string, tags tagResolver) (*registry.RecordReleaseRequest, error) {
+	m, err := manifest.ReadManifest(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	if errs := manifest.LintManifestPath(m, r.Path); len(errs) > 0 {
+		return nil, fmt.Errorf("manifest does not match its path: %s", errs[0])
+	}
+	commitSha, err := releaseCommit(m)
+	if err != nil {
+		return nil, err
+	}
+	if commitSha == "" {
+		if tags == nil {
+			return nil, fmt.Errorf("%w: manifest records no vcs_revision or image revision", errNoCommit)
+		}
+		if commitSha, err = tags.TagCommit(ctx, m.GetOrg(), m.GetName(), m.GetSemver()); err != nil {
+			return nil, err
+		}
+	}
+	return &registry.RecordReleaseRequest{
+		Org:            m.GetOrg(),
+		Name:           m.GetName(),
+		Version:        m.GetSemver(),
+		RepositoryURL:  "https://github.com/" + m.GetOrg() + "/" + m.GetName(),
+		CommitSha:      commitSha,
+		WorkflowRunID:  workflowRunID,
+		SignatureURL:   m.GetSignatureHref(),
+		CertificateURL: m.GetCertificateHref(),
+		Assets:         registry.TransformAssets(m),
+		Images:         registry.TransformImages(m),
+		ReleasedAt:     releasedAt(m),
+	}, nil
+}
+
+// releaseCommit returns the commit the release was built from: the
+// vcs_revision of its assets, or failing that the revision of its images.
+// Every recorded revision must agree. It returns "" when none is recorded.
+func releaseCommit(m *pb.Manifest) (string, error) {
+	revisions := make(map[string][]string)
+	for _, key := range manifest.SortedKeys(m.GetAssets()) {
+		if rev := strings.ToLower(m.GetAssets()[key].GetVcsRevision()); rev != "" {
+			revisions[rev] = append(revisions[rev], "assets."+key)
+		}
+	}
+	for _, key := range manifest.SortedKeys(m.GetImages()) {
+		if rev := strings.ToLower(m.GetImages()[key].GetRevision()); rev != "" {
+			revisions[rev] = append(revisions[rev], "images."+key)
+		}
+	}
+	switch len(revisions) {
+	case 0:
+		return "", nil
+	case 1:
+		for rev := range revisions {
+			return rev, nil
+		}
+	}
+	var parts []string
+	for _, rev := range manifest.SortedKeys(revisions) {
+		parts = append(parts, fmt.Sprintf("%s (%s)", rev, strings.Join(revisions[rev], ", ")))
+	}
+	return "", fmt.Errorf("manifest records more than one commit: %s", strings.Join(parts, "; "))
+}
+
+// tagResolver finds the commit a release tag points to.
+type tagResolver interface {
+	TagCommit(ctx context.Context, org, repo, tag string) (string, error)
+}
+
+// gitTags resolves tags with git ls-remote against {BaseURL}/{org}/{repo}.
+// Private repositories need credentials configured for git.
+type gitTags struct {
+	BaseURL string
+}
+
+// TagCommit returns the commit tag points to, peeling annotated tags. A tag
+// that does not exist is reported as errNoCommit.
+func (g *gitTags) TagCommit(ctx context.Context, org, repo, tag string) (string, error) {
+	remote := strings.TrimSuffix(g.BaseURL, "/") + "/" + org + "/" + repo
+	ref := "refs/tags/" + tag
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", remote, ref, ref+"^{}")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote %s: %w: %s", remote, err, strings.TrimSpace(stderr.String()))
+	}
+	var commit string
+	for _, line := range strings.Split(string(out), "\n") {
+		sha, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		switch name {
+		case ref + "^{}":
+			return sha, nil
+		case ref:
+			commit = sha
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("%w: manifest records no revision and %s has no tag %s", errNoCommit, remote, tag)
+	}
+	return commit, nil
+}
+
+func releasedAt(m *pb.Manifest) string {
+	if !m.HasReleasedAt() {
+		return ""
+	}
+	return m.GetReleasedAt().AsTime().UTC().Format(time.RFC3339)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// progress is an append-only file of release keys that were recorded, so an
+// interrupted backfill can resume where it stopped.
+type progress struct {
+	f    *os.File
+	done map[string]bool
+}
+
+func openProgress(path string) (*progress, error) {
+	p := &progress{done: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading progress: %w", err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			p.done[key] = true
+		}
+	}
+	p.f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening progress: %w", err)
+	}
+	return p, nil
+}
+
+// Done reports whether key was recorded by an earlier run.
+func (p *progress) Done(key string) bool {
+	return p.done[key]
+}
+
+// MarkDone appends key to the progress file.
+func (p *progress) MarkDone(key string) error {
+	p.done[key] = true
+	if _, err := fmt.Fprintln(p.f, key); err != nil {
+		return fmt.Errorf("writing progress: %w", err)
+	}
+	return nil
+}
+
+// Close closes the progress file.
+func (p *progress) Close() error {
+	return p.f.Close()
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	"github.com/ConductorOne/github-workflows/internal/registry"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const testCommit = "0123456789abcdef0123456789abcdef01234567"
+
+func writeManifest(t *testing.T, root, org, repo, tag string) {
+	t.Helper()
+	writeManifestProto(t, root, pb.Manifest_builder{
+		Version:    strPtr(manifest.SchemaVersion),
+		Org:        strPtr(org),
+		Name:       strPtr(repo),
+		Semver:     strPtr(tag),
+		ReleasedAt: timestamppb.New(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{Filename: strPtr(repo + "-" + tag + "-linux-amd64.tar.gz"), VcsRevision: strPtr(testCommit)}.Build(),
+		},
+	}.Build())
+}
+
+func writeManifestProto(t *testing.T, root string, m *pb.Manifest) {
+	t.Helper()
+	org, repo, tag := m.GetOrg(), m.GetName(), m.GetSemver()
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, org, repo, tag)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillResumesAndTreatsConflictAsDone(t *testing.T) {
+	root := t.TempDir()
+	writeManifest(t, root, "ConductorOne", "baton-a", "v0.1.0")
+	writeManifest(t, root, "ConductorOne", "baton-a", "v0.2.0")
+	writeManifest(t, root, "ConductorOne", "baton-b", "v1.0.0")
+	// stable.json and manifests at other depths are ignored.
+	if err := os.WriteFile(filepath.Join(root, "ConductorOne", "baton-a", "stable.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var received []*registry.RecordReleaseRequest
+	failB := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &registry.RecordReleaseRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, req)
+		switch {
+		case req.Version == "v0.1.0":
+			w.WriteHeader(http.StatusConflict)
+		case req.Name == "baton-b" && failB:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	progressPath := filepath.Join(t.TempDir(), "progress.txt")
+	run := func() *summary {
+		t.Helper()
+		p, err := openProgress(progressPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+		b := &backfiller{
+			client:        &registry.Client{Endpoint: srv.URL + registry.IngestPath, Token: "t", HTTPClient: srv.Client(), Retry: registry.RetryPolicy{MaxAttempts: 1}},
+			progress:      p,
+			out:           io.Discard,
+			workflowRunID: "42",
+		}
+		s, err := b.run(context.Background(), root)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return s
+	}
+
+	s := run()
+	if *s != (summary{Recorded: 1, AlreadyPresent: 1, Failed: 1}) {
+		t.Fatalf("first run = %+v", *s)
+	}
+	if received[1].ReleasedAt != "2024-05-06T07:08:09Z" || received[1].RepositoryURL != "https://github.com/ConductorOne/baton-a" ||
+		received[1].CommitSha != testCommit || received[1].WorkflowRunID != "42" {
+		t.Fatalf("request = %+v", received[1])
+	}
+
+	failB = false
+	s = run()
+	if *s != (summary{Recorded: 1, Skipped: 2}) {
+		t.Fatalf("second run = %+v", *s)
+	}
+
+	data, err := os.ReadFile(progressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ConductorOne/baton-a/v0.1.0\nConductorOne/baton-a/v0.2.0\nConductorOne/baton-b/v1.0.0\n"
+	if string(data) != want {
+		t.Fatalf("progress file:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestBuildRequestRejectsMisplacedManifest(t *testing.T) {
+	root := t.TempDir()
+	writeManifest(t, root, "ConductorOne", "baton-a", "v0.1.0")
+	path := filepath.Join(root, "ConductorOne", "baton-a", "v0.1.0", "manifest.json")
+	wrong := filepath.Join(root, "ConductorOne", "baton-a", "v0.9.0")
+	if err := os.MkdirAll(wrong, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, filepath.Join(wrong, "manifest.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err := findReleases(root)
+	if err != nil || len(releases) != 1 {
+		t.Fatalf("findReleases = %v, %v", releases, err)
+	}
+	_, err = buildRequest(context.Background(), releases[0], "42", nil)
+	if err == nil || !strings.Contains(err.Error(), "semver") {
+		t.Fatalf("buildRequest err = %v, want semver mismatch", err)
+	}
+}
+
+func TestBackfillSkipsReleasesWithoutCommit(t *testing.T) {
+	root := t.TempDir()
+	writeManifestProto(t, root, pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("ConductorOne"),
+		Name:    strPtr("baton-a"),
+		Semver:  strPtr("v0.1.0"),
+	}.Build())
+	writeManifestProto(t, root, pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("ConductorOne"),
+		Name:    strPtr("baton-a"),
+		Semver:  strPtr("v0.2.0"),
+		Images: map[string]*pb.Image{
+			"ecrPublic": pb.Image_builder{Ref: strPtr("public.ecr.aws/conductorone/baton-a:0.2.0"), Revision: strPtr(strings.ToUpper(testCommit))}.Build(),
+		},
+	}.Build())
+
+	var out strings.Builder
+	b := &backfiller{out: &out, dryRun: true}
+	s, err := b.run(context.Background(), root)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if *s != (summary{NoCommit: 1}) {
+		t.Fatalf("summary = %+v", *s)
+	}
+	if !strings.Contains(out.String(), "ConductorOne/baton-a/v0.1.0: skipped") || !strings.Contains(out.String(), "commit "+testCommit) {
+		t.Fatalf("output:\n%s", out.String())
+	}
+}
+
+func TestBackfillResolvesCommitFromTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	// The remote for ConductorOne/baton-a is a local repository with an
+	// annotated v0.1.0 tag and no v0.2.0 tag.
+	base := t.TempDir()
+	dir := filepath.Join(base, "ConductorOne", "baton-a")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "release")
+	git("tag", "-a", "v0.1.0", "-m", "v0.1.0")
+	commit := git("rev-parse", "HEAD")
+
+	// Manifests from before build info and image revisions were recorded.
+	root := t.TempDir()
+	for _, tag := range []string{"v0.1.0", "v0.2.0"} {
+		writeManifestProto(t, root, pb.Manifest_builder{
+			Version: strPtr(manifest.SchemaVersion),
+			Org:     strPtr("ConductorOne"),
+			Name:    strPtr("baton-a"),
+			Semver:  strPtr(tag),
+		}.Build())
+	}
+
+	var out strings.Builder
+	b := &backfiller{out: &out, dryRun: true, tags: &gitTags{BaseURL: base}}
+	s, err := b.run(context.Background(), root)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if *s != (summary{NoCommit: 1}) {
+		t.Fatalf("summary = %+v", *s)
+	}
+	if !strings.Contains(out.String(), "ConductorOne/baton-a/v0.1.0 (commit "+commit) {
+		t.Fatalf("v0.1.0 was not resolved to the tagged commit %s:\n%s", commit, out.String())
+	}
+	if !strings.Contains(out.String(), "ConductorOne/baton-a/v0.2.0: skipped") || !strings.Contains(out.String(), "has no tag v0.2.0") {
+		t.Fatalf("v0.2.0 should be skipped for its missing tag:\n%s", out.String())
+	}
+}
+
+func TestReleaseCommitRejectsDisagreeingRevisions(t *testing.T) {
+	m := pb.Manifest_builder{
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{VcsRevision: strPtr(testCommit)}.Build(),
+		},
+		Images: map[string]*pb.Image{
+			"ecrPublic": pb.Image_builder{Revision: strPtr("fedcba9876543210fedcba9876543210fedcba98")}.Build(),
+		},
+	}.Build()
+	_, err := releaseCommit(m)
+	if err == nil || !strings.Contains(err.Error(), "assets.linux-amd64") || !strings.Contains(err.Error(), "images.ecrPublic") {
+		t.Fatalf("err = %v, want both sources named", err)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
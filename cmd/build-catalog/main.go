@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/pkg/distclient"
+)
+
+func main() {
+	var (
+		root       string
+		org        string
+		repo       string
+		baseURL    string
+		outputPath string
+	)
+	flag.StringVar(&root, "root", "releases", "Directory laid out as {org}/{repo}/{tag}/manifest.json (e.g. an S3 sync of releases/)")
+	flag.StringVar(&org, "org", "", "GitHub organization (required)")
+	flag.StringVar(&repo, "repo", "", "Repository to index (optional, defaults to every repository in the org)")
+	flag.StringVar(&baseURL, "base-url", distclient.DefaultBaseURL, "Base URL used for each version's manifest_href")
+	flag.StringVar(&outputPath, "output", "", "Path to write index.json (optional, defaults to stdout)")
+	flag.Parse()
+
+	if org == "" {
+		fmt.Fprintf(os.Stderr, "build-catalog: error: org is required\n")
+		os.Exit(1)
+	}
+
+	b := &catalogBuilder{
+		root:    root,
+		baseURL: baseURL,
+		warnf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "build-catalog: warning: "+format+"\n", args...)
+		},
+	}
+	catalog, err := b.build(org, repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build-catalog: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	jsonBytes, err := manifest.Marshal(catalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build-catalog: error: marshaling catalog: %v\n", err)
+		os.Exit(1)
+	}
+	if outputPath == "" {
+		fmt.Println(string(jsonBytes))
+	} else if err := os.WriteFile(outputPath, append(jsonBytes, '\n'), 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "build-catalog: error: writing catalog: %v\n", err)
+		os.Exit(1)
+	}
+
+	versions := 0
+	for _, r := range catalog.GetRepositories() {
+		versions += len(r.GetVersions())
+	}
+	fmt.Fprintf(os.Stderr, "✅ Indexed %d versions across %d repositories\n", versions, len(catalog.GetRepositories()))
+}
+
+// catalogBuilder reads versioned manifests and stable.json files from a local
+// mirror of the releases tree.
+type catalogBuilder struct {
+	root    string
+	baseURL string
+	// warnf reports manifests that are skipped.
+	warnf func(format string, args ...any)
+}
+
+// build indexes repo, or every repository under org when repo is empty.
+func (b *catalogBuilder) build(org, repo string) (*pb.Catalog, error) {
+	names := []string{repo}
+	if repo == "" {
+		var err error
+		if names, err = subdirectories(filepath.Join(b.root, org)); err != nil {
+			return nil, err
+		}
+	}
+
+	var repositories []*pb.CatalogRepository
+	for _, name := range names {
+		r, err := b.repository(org, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.GetVersions()) == 0 {
+			b.warnf("%s/%s has no versioned manifests", org, name)
+			continue
+		}
+		repositories = append(repositories, r)
+	}
+	if len(repositories) == 0 {
+		return nil, fmt.Errorf("no manifests found under %s", filepath.Join(b.root, org, repo))
+	}
+
+	return pb.Catalog_builder{
+		Version:      stringPtr(manifest.CatalogSchemaVersion),
+		Org:          stringPtr(org),
+		Repositories: repositories,
+	}.Build(), nil
+}
+
+func (b *catalogBuilder) repository(org, name string) (*pb.CatalogRepository, error) {
+	dir := filepath.Join(b.root, org, name)
+	tags, err := subdirectories(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*pb.CatalogVersion
+	for _, tag := range tags {
+		path := filepath.Join(dir, tag, "manifest.json")
+		m, err := manifest.ReadManifest(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !semver.IsValid(m.GetSemver()) {
+			b.warnf("skipping %s: semver %q is not a valid semantic version", path, m.GetSemver())
+			continue
+		}
+		if errs := manifest.LintManifestPath(m, path); len(errs) > 0 {
+			b.warnf("skipping %s: %s", path, errs[0])
+			continue
+		}
+		versions = append(versions, b.version(org, name, m))
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i].GetSemver(), versions[j].GetSemver()) < 0
+	})
+
+	r := pb.CatalogRepository_builder{
+		Name:     stringPtr(name),
+		Versions: versions,
+	}.Build()
+
+	stable, err := manifest.ReadStable(filepath.Join(dir, "stable.json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		stableSemver := stable.GetManifest().GetSemver()
+		if !hasVersion(versions, stableSemver) {
+			b.warnf("%s/%s stable.json points to %q, which has no versioned manifest", org, name, stableSemver)
+		}
+		r.SetStable(stableSemver)
+	}
+	return r, nil
+}
+
+func (b *catalogBuilder) version(org, name string, m *pb.Manifest) *pb.CatalogVersion {
+	platforms := make([]string, 0, len(m.GetAssets()))
+	for key := range m.GetAssets() {
+		if isPlatformKey(key) {
+			platforms = append(platforms, key)
+		}
+	}
+	sort.Strings(platforms)
+
+	digests := make(map[string]string, len(m.GetImages()))
+	for key, image := range m.GetImages() {
+		digests[key] = image.GetDigest()
+	}
+
+	isPrerelease := semver.Prerelease(m.GetSemver()) != ""
+	return pb.CatalogVersion_builder{
+		Semver:       stringPtr(m.GetSemver()),
+		ReleasedAt:   m.GetReleasedAt(),
+		Platforms:    platforms,
+		ImageDigests: digests,
+		ManifestHref: stringPtr(manifest.JoinURL(b.baseURL, org+"/"+name+"/"+m.GetSemver()+"/manifest.json")),
+		Prerelease:   &isPrerelease,
+	}.Build()
+}
+
+// isPlatformKey reports whether an asset key is a "{goos}-{goarch}" archive
+// key, as opposed to "checksums" or an installer variant such as
+// "windows-amd64-msi".
+func isPlatformKey(key string) bool {
+	goos, goarch, ok := strings.Cut(key, "-")
+	return ok && goos != "" && goarch != "" && !strings.Contains(goarch, "-")
+}
+
+func hasVersion(versions []*pb.CatalogVersion, v string) bool {
+	for _, version := range versions {
+		if version.GetSemver() == v {
+			return true
+		}
+	}
+	return false
+}
+
+// subdirectories returns the sorted names of the directories in dir.
+func subdirectories(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/pkg/distclient"
+)
+
+func testManifest(org, repo, tag string) *pb.Manifest {
+	return pb.Manifest_builder{
+		Version:    stringPtr(manifest.SchemaVersion),
+		Org:        stringPtr(org),
+		Name:       stringPtr(repo),
+		Semver:     stringPtr(tag),
+		ReleasedAt: timestamppb.New(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64":  pb.Asset_builder{Filename: stringPtr("a.tar.gz")}.Build(),
+			"darwin-arm64": pb.Asset_builder{Filename: stringPtr("b.zip")}.Build(),
+			// Neither is a platform.
+			"checksums":         pb.Asset_builder{Filename: stringPtr("checksums.txt")}.Build(),
+			"windows-amd64-msi": pb.Asset_builder{Filename: stringPtr("c.msi")}.Build(),
+		},
+		Images: map[string]*pb.Image{
+			"ecrPublic": pb.Image_builder{Digest: stringPtr("sha256:" + strings.Repeat("a", 64))}.Build(),
+		},
+	}.Build()
+}
+
+func writeJSON(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeManifest(t *testing.T, root, org, repo, tag string) {
+	t.Helper()
+	data, err := manifest.Marshal(testManifest(org, repo, tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(root, org, repo, tag, "manifest.json"), data)
+}
+
+func TestBuildCatalog(t *testing.T) {
+	root := t.TempDir()
+	for _, tag := range []string{"v0.1.0", "v0.10.0", "v0.2.0", "v1.0.0-rc.1"} {
+		writeManifest(t, root, "ConductorOne", "baton-a", tag)
+	}
+	writeManifest(t, root, "ConductorOne", "baton-b", "v1.0.0")
+	// A manifest stored under the wrong tag is skipped.
+	data, err := manifest.Marshal(testManifest("ConductorOne", "baton-b", "v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(root, "ConductorOne", "baton-b", "v2.0.0", "manifest.json"), data)
+
+	stable := pb.Stable_builder{
+		Version:  stringPtr(manifest.StableSchemaVersion),
+		Manifest: testManifest("ConductorOne", "baton-a", "v0.10.0"),
+	}.Build()
+	data, err = manifest.Marshal(stable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(root, "ConductorOne", "baton-a", "stable.json"), data)
+
+	var warnings []string
+	b := &catalogBuilder{
+		root:    root,
+		baseURL: "https://dist.example.com/releases",
+		warnf:   func(format string, args ...any) { warnings = append(warnings, format) },
+	}
+	catalog, err := b.build("ConductorOne", "")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want one for the misplaced manifest", warnings)
+	}
+
+	repos := catalog.GetRepositories()
+	if len(repos) != 2 || repos[0].GetName() != "baton-a" || repos[1].GetName() != "baton-b" {
+		t.Fatalf("repositories = %v", repos)
+	}
+	a := repos[0]
+	if a.GetStable() != "v0.10.0" {
+		t.Fatalf("stable = %q, want v0.10.0", a.GetStable())
+	}
+	var got []string
+	for _, v := range a.GetVersions() {
+		got = append(got, v.GetSemver())
+	}
+	if want := "v0.1.0,v0.2.0,v0.10.0,v1.0.0-rc.1"; strings.Join(got, ",") != want {
+		t.Fatalf("versions = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	v := a.GetVersions()[3]
+	if !v.GetPrerelease() {
+		t.Fatal("v1.0.0-rc.1 is not marked prerelease")
+	}
+	if strings.Join(v.GetPlatforms(), ",") != "darwin-arm64,linux-amd64" {
+		t.Fatalf("platforms = %v", v.GetPlatforms())
+	}
+	if v.GetImageDigests()["ecrPublic"] == "" {
+		t.Fatalf("image digests = %v", v.GetImageDigests())
+	}
+	if v.GetManifestHref() != "https://dist.example.com/releases/ConductorOne/baton-a/v1.0.0-rc.1/manifest.json" {
+		t.Fatalf("manifest_href = %q", v.GetManifestHref())
+	}
+	if !v.GetReleasedAt().AsTime().Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)) {
+		t.Fatalf("released_at = %v", v.GetReleasedAt().AsTime())
+	}
+}
+
+func TestBuildCatalogSingleRepository(t *testing.T) {
+	root := t.TempDir()
+	writeManifest(t, root, "ConductorOne", "baton-a", "v0.1.0")
+	writeManifest(t, root, "ConductorOne", "baton-b", "v0.1.0")
+
+	b := &catalogBuilder{root: root, baseURL: distclient.DefaultBaseURL, warnf: t.Logf}
+	catalog, err := b.build("ConductorOne", "baton-b")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(catalog.GetRepositories()) != 1 || catalog.GetRepositories()[0].GetStable() != "" {
+		t.Fatalf("repositories = %v", catalog.GetRepositories())
+	}
+
+	if _, err := b.build("ConductorOne", "baton-missing"); err == nil {
+		t.Fatal("expected error for a repository with no manifests")
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// Change kinds.
+const (
+	kindAdded   = "added"
+	kindRemoved = "removed"
+	kindChanged = "changed"
+)
+
+// Change sections.
+const (
+	sectionManifest = "manifest"
+	sectionAsset    = "asset"
+	sectionImage    = "image"
+)
+
+// Change is one difference between two manifests.
+type Change struct {
+	Kind    string `json:"kind"`
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Field   string `json:"field,omitempty"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+// Diff is the structured difference between an old and a new manifest.
+type Diff struct {
+	Name    string    `json:"name"`
+	Old     string    `json:"old"`
+	New     string    `json:"new"`
+	Changes []*Change `json:"changes"`
+}
+
+func main() {
+	var (
+		oldPath       string
+		newPath       string
+		format        string
+		sizeThreshold float64
+	)
+	flag.StringVar(&oldPath, "old", "", "Path to the older manifest.json (required)")
+	flag.StringVar(&newPath, "new", "", "Path to the newer manifest.json (required)")
+	flag.StringVar(&format, "format", "text", "Output format: text, json or markdown")
+	flag.Float64Var(&sizeThreshold, "size-threshold", 10, "Report asset size changes of at least this many percent (0 reports every change)")
+	flag.Parse()
+
+	if oldPath == "" || newPath == "" {
+		fmt.Fprintf(os.Stderr, "diff-manifests: error: old and new are required\n")
+		os.Exit(1)
+	}
+
+	oldManifest, err := manifest.ReadManifest(oldPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diff-manifests: error: %v\n", err)
+		os.Exit(1)
+	}
+	newManifest, err := manifest.ReadManifest(newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diff-manifests: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	d := diffManifests(oldManifest, newManifest, sizeThreshold)
+
+	switch format {
+	case "text":
+		err = writeText(os.Stdout, d)
+	case "json":
+		err = writeJSON(os.Stdout, d)
+	case "markdown":
+		err = writeMarkdown(os.Stdout, d)
+	default:
+		fmt.Fprintf(os.Stderr, "diff-manifests: error: format must be text, json or markdown, got %q\n", format)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diff-manifests: error: writing diff: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// diffManifests compares two manifests. Fields that change on every release
+// (filenames, hrefs, hashes) are not reported; sizes are only reported when they
+// change by at least sizeThreshold percent.
+func diffManifests(oldManifest, newManifest *pb.Manifest, sizeThreshold float64) *Diff {
+	d := &Diff{Name: newManifest.GetName(), Old: oldManifest.GetSemver(), New: newManifest.GetSemver()}
+	changed := func(section, key, field, oldValue, newValue string) {
+		if oldValue != newValue {
+			d.Changes = append(d.Changes, &Change{Kind: kindChanged, Section: section, Key: key, Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	changed(sectionManifest, "", "name", oldManifest.GetName(), newManifest.GetName())
+	changed(sectionManifest, "", "org", oldManifest.GetOrg(), newManifest.GetOrg())
+	changed(sectionManifest, "", "version", oldManifest.GetVersion(), newManifest.GetVersion())
+	changed(sectionManifest, "", "asset_attestation", describeAttestation(oldManifest.GetAssetAttestation()), describeAttestation(newManifest.GetAssetAttestation()))
+	changed(sectionManifest, "", "asset_attestations", describeAttestations(oldManifest.GetAssetAttestations()), describeAttestations(newManifest.GetAssetAttestations()))
+	changed(sectionManifest, "", "image_attestation", describeAttestation(oldManifest.GetImageAttestation()), describeAttestation(newManifest.GetImageAttestation()))
+
+	oldAssets, newAssets := oldManifest.GetAssets(), newManifest.GetAssets()
+	for _, key := range unionKeys(oldAssets, newAssets) {
+		oldAsset, inOld := oldAssets[key]
+		newAsset, inNew := newAssets[key]
+		switch {
+		case !inOld:
+			d.Changes = append(d.Changes, &Change{Kind: kindAdded, Section: sectionAsset, Key: key, New: newAsset.GetFilename()})
+		case !inNew:
+			d.Changes = append(d.Changes, &Change{Kind: kindRemoved, Section: sectionAsset, Key: key, Old: oldAsset.GetFilename()})
+		default:
+			changed(sectionAsset, key, "media_type", oldAsset.GetMediaType(), newAsset.GetMediaType())
+			if sizeChanged(oldAsset.GetSizeBytes(), newAsset.GetSizeBytes(), sizeThreshold) {
+				changed(sectionAsset, key, "size_bytes", fmt.Sprint(oldAsset.GetSizeBytes()), fmt.Sprintf("%d (%s)", newAsset.GetSizeBytes(), sizeDelta(oldAsset.GetSizeBytes(), newAsset.GetSizeBytes())))
+			}
+			changed(sectionAsset, key, "signature", present(oldAsset.GetSignatureHref()), present(newAsset.GetSignatureHref()))
+			changed(sectionAsset, key, "certificate", present(oldAsset.GetCertificateHref()), present(newAsset.GetCertificateHref()))
+			d.Changes = append(d.Changes, diffAttestations(key, oldAsset.GetAttestations(), newAsset.GetAttestations())...)
+		}
+	}
+
+	oldImages, newImages := oldManifest.GetImages(), newManifest.GetImages()
+	for _, key := range unionKeys(oldImages, newImages) {
+		oldImage, inOld := oldImages[key]
+		newImage, inNew := newImages[key]
+		switch {
+		case !inOld:
+			d.Changes = append(d.Changes, &Change{Kind: kindAdded, Section: sectionImage, Key: key, New: newImage.GetUri()})
+		case !inNew:
+			d.Changes = append(d.Changes, &Change{Kind: kindRemoved, Section: sectionImage, Key: key, Old: oldImage.GetUri()})
+		default:
+			changed(sectionImage, key, "repository", repository(oldImage.GetRef()), repository(newImage.GetRef()))
+			changed(sectionImage, key, "digest", oldImage.GetDigest(), newImage.GetDigest())
+			changed(sectionImage, key, "is_index", fmt.Sprint(oldImage.GetIsIndex()), fmt.Sprint(newImage.GetIsIndex()))
+			changed(sectionImage, key, "platforms", describePlatforms(oldImage.GetPlatforms()), describePlatforms(newImage.GetPlatforms()))
+		}
+	}
+	return d
+}
+
+// diffAttestations reports attestation predicate types added to or removed from an asset.
+func diffAttestations(key string, oldAttestations, newAttestations []*pb.AttestationDescriptor) []*Change {
+	oldTypes, newTypes := predicateTypes(oldAttestations), predicateTypes(newAttestations)
+	var changes []*Change
+	for _, t := range unionKeys(oldTypes, newTypes) {
+		switch {
+		case !oldTypes[t]:
+			changes = append(changes, &Change{Kind: kindAdded, Section: sectionAsset, Key: key, Field: "attestation", New: t})
+		case !newTypes[t]:
+			changes = append(changes, &Change{Kind: kindRemoved, Section: sectionAsset, Key: key, Field: "attestation", Old: t})
+		}
+	}
+	return changes
+}
+
+func predicateTypes(attestations []*pb.AttestationDescriptor) map[string]bool {
+	types := make(map[string]bool, len(attestations))
+	for _, att := range attestations {
+		types[att.GetPredicateType()] = true
+	}
+	return types
+}
+
+func describeAttestation(att *pb.AttestationDescriptor) string {
+	if att == nil {
+		return ""
+	}
+	return att.GetPredicateType()
+}
+
+func describeAttestations(atts []*pb.AttestationDescriptor) string {
+	types := make([]string, 0, len(atts))
+	for _, att := range atts {
+		types = append(types, att.GetPredicateType())
+	}
+	return strings.Join(types, ", ")
+}
+
+// describePlatforms lists os/architecture[/variant]; child digests change on
+// every release and are not compared.
+func describePlatforms(platforms []*pb.ImagePlatform) string {
+	names := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		name := p.GetOs() + "/" + p.GetArchitecture()
+		if p.GetVariant() != "" {
+			name += "/" + p.GetVariant()
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func present(href string) string {
+	if href == "" {
+		return "absent"
+	}
+	return "present"
+}
+
+// repository returns ref without its tag.
+func repository(ref string) string {
+	repo, _, _ := manifest.SplitImageRef(ref)
+	return repo
+}
+
+func sizeChanged(oldSize, newSize int64, thresholdPercent float64) bool {
+	if oldSize == newSize {
+		return false
+	}
+	if oldSize == 0 {
+		return true
+	}
+	return math.Abs(float64(newSize-oldSize))*100/float64(oldSize) >= thresholdPercent
+}
+
+func sizeDelta(oldSize, newSize int64) string {
+	if oldSize == 0 {
+		return fmt.Sprintf("%+d bytes", newSize)
+	}
+	return fmt.Sprintf("%+.1f%%", float64(newSize-oldSize)*100/float64(oldSize))
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Change) subject() string {
+	s := c.Section
+	if c.Key != "" {
+		s += " " + c.Key
+	}
+	if c.Field != "" {
+		s += " " + c.Field
+	}
+	return s
+}
+
+func writeText(w io.Writer, d *Diff) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s → %s\n", d.Name, d.Old, d.New)
+	if len(d.Changes) == 0 {
+		sb.WriteString("No changes\n")
+	}
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case kindAdded:
+			fmt.Fprintf(&sb, "+ %s: %s\n", c.subject(), c.New)
+		case kindRemoved:
+			fmt.Fprintf(&sb, "- %s: %s\n", c.subject(), c.Old)
+		default:
+			fmt.Fprintf(&sb, "~ %s: %s → %s\n", c.subject(), orNone(c.Old), orNone(c.New))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdown(w io.Writer, d *Diff) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s `%s` → `%s`\n\n", d.Name, d.Old, d.New)
+	if len(d.Changes) == 0 {
+		sb.WriteString("No changes.\n")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+	sb.WriteString("| Change | Section | Key | Field | Old | New |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, c := range d.Changes {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n", c.Kind, c.Section, mdCode(c.Key), c.Field, mdCode(c.Old), mdCode(c.New))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeJSON(w io.Writer, d *Diff) error {
+	if d.Changes == nil {
+		d.Changes = []*Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func mdCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
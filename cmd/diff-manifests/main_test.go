@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func testAsset(filename string, size int64, predicateTypes ...string) *pb.Asset {
+	var attestations []*pb.AttestationDescriptor
+	for _, t := range predicateTypes {
+		attestations = append(attestations, manifest.NewAttestation(t, "https://example.com/"+filename+".bundle"))
+	}
+	return pb.Asset_builder{
+		Filename:     strPtr(filename),
+		MediaType:    strPtr("application/zip"),
+		SizeBytes:    &size,
+		Href:         strPtr("https://example.com/" + filename),
+		Attestations: attestations,
+	}.Build()
+}
+
+func testManifest(semver string, assets map[string]*pb.Asset, images map[string]*pb.Image) *pb.Manifest {
+	return pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("conductorone"),
+		Name:    strPtr("baton-example"),
+		Semver:  strPtr(semver),
+		Assets:  assets,
+		Images:  images,
+	}.Build()
+}
+
+func testImage(tag, digest string) *pb.Image {
+	isIndex := true
+	return pb.Image_builder{
+		Ref:     strPtr("public.ecr.aws/conductorone/baton-example:" + tag),
+		Digest:  strPtr(digest),
+		Uri:     strPtr("public.ecr.aws/conductorone/baton-example@" + digest),
+		IsIndex: &isIndex,
+	}.Build()
+}
+
+func TestDiffManifests(t *testing.T) {
+	oldManifest := testManifest("v0.1.97",
+		map[string]*pb.Asset{
+			"darwin-arm64":  testAsset("baton-example-v0.1.97-darwin-arm64.zip", 1000, manifest.PredicateTypeSLSAProvenanceV1, manifest.PredicateTypeSPDX),
+			"linux-amd64":   testAsset("baton-example-v0.1.97-linux-amd64.tar.gz", 1000, manifest.PredicateTypeSLSAProvenanceV1),
+			"windows-amd64": testAsset("baton-example-v0.1.97-windows-amd64.zip", 1000),
+		},
+		map[string]*pb.Image{"ecrPublic": testImage("v0.1.97", "sha256:aaaa")},
+	)
+	newManifest := testManifest("v0.1.98",
+		map[string]*pb.Asset{
+			"darwin-arm64":  testAsset("baton-example-v0.1.98-darwin-arm64.zip", 1050, manifest.PredicateTypeSLSAProvenanceV1),
+			"linux-amd64":   testAsset("baton-example-v0.1.98-linux-amd64.tar.gz", 2000, manifest.PredicateTypeSLSAProvenanceV1),
+			"freebsd-amd64": testAsset("baton-example-v0.1.98-freebsd-amd64.tar.gz", 1000),
+		},
+		map[string]*pb.Image{"ecrPublic": testImage("v0.1.98", "sha256:bbbb")},
+	)
+
+	d := diffManifests(oldManifest, newManifest, 10)
+	var got []string
+	for _, c := range d.Changes {
+		got = append(got, c.Kind+" "+c.subject())
+	}
+	want := []string{
+		"removed asset darwin-arm64 attestation",
+		"added asset freebsd-amd64",
+		"changed asset linux-amd64 size_bytes",
+		"removed asset windows-amd64",
+		"changed image ecrPublic digest",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if c := d.Changes[2]; c.Old != "1000" || c.New != "2000 (+100.0%)" {
+		t.Fatalf("size change = %q → %q", c.Old, c.New)
+	}
+
+	// A zero threshold reports the 5% darwin-arm64 growth too.
+	d = diffManifests(oldManifest, newManifest, 0)
+	if len(d.Changes) != len(want)+1 {
+		t.Fatalf("got %d changes with zero threshold, want %d", len(d.Changes), len(want)+1)
+	}
+}
+
+func TestDiffIdenticalManifests(t *testing.T) {
+	m := testManifest("v0.1.0", map[string]*pb.Asset{"linux-amd64": testAsset("a.tar.gz", 10)}, nil)
+	d := diffManifests(m, m, 0)
+	if len(d.Changes) != 0 {
+		t.Fatalf("changes = %v, want none", d.Changes)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, d); err != nil {
+		t.Fatalf("writeJSON: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if changes, ok := decoded["changes"].([]any); !ok || len(changes) != 0 {
+		t.Fatalf("changes = %v, want empty array", decoded["changes"])
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	d := &Diff{Name: "baton-example", Old: "v0.1.0", New: "v0.2.0", Changes: []*Change{
+		{Kind: kindAdded, Section: sectionAsset, Key: "linux-arm64", New: "baton-example-v0.2.0-linux-arm64.tar.gz"},
+	}}
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, d); err != nil {
+		t.Fatalf("writeMarkdown: %v", err)
+	}
+	want := "| added | asset | `linux-arm64` |  |  | `baton-example-v0.2.0-linux-arm64.tar.gz` |\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Fatalf("markdown:\n%s\nwant row:\n%s", buf.String(), want)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -7,12 +7,30 @@ import (
 	"sort"
 	"strings"
 
-	"google.golang.org/protobuf/encoding/protojson"
-
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	"github.com/ConductorOne/github-workflows/internal/oci"
 	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
 )
 
-const lambdaArm64ImageKey = "lambda-arm64"
+// imageRuleFlags collects repeated -image flags.
+type imageRuleFlags []manifest.ImageRule
+
+func (f *imageRuleFlags) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, r := range *f {
+		parts = append(parts, r.Key+"="+r.Prefix)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *imageRuleFlags) Set(value string) error {
+	r, err := manifest.ParseImageRule(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, r)
+	return nil
+}
 
 func main() {
 	var (
@@ -24,6 +42,10 @@ func main() {
 		tag              string
 		includePublic    bool
 		includeLambda    bool
+		imageRulesPath   string
+		imageOverrides   imageRuleFlags
+		ociLayoutDir     string
+		ociRepository    string
 	)
 	flag.StringVar(&assetDir, "asset-dir", "../_caller/dist", "Directory containing asset files")
 	flag.StringVar(&digestFile, "digest-file", "", "Path to digest file (if not provided, will be constructed from repo-name, tag, and asset-dir)")
@@ -31,8 +53,12 @@ func main() {
 	flag.StringVar(&lambdaDigestFile, "lambda-digest-file", "", "Path to Lambda digest file (if not provided, will be constructed from repo-name, tag, and lambda-asset-dir)")
 	flag.StringVar(&repoName, "repo-name", "", "Repository name")
 	flag.StringVar(&tag, "tag", "", "Release tag (e.g., v0.1.65 or 0.1.65)")
-	flag.BoolVar(&includePublic, "include-public", true, "Extract ECR public image metadata")
-	flag.BoolVar(&includeLambda, "include-lambda", false, "Extract private Lambda image metadata")
+	flag.BoolVar(&includePublic, "include-public", true, "Apply the image rules that read the docker digest file (ECR public by default)")
+	flag.BoolVar(&includeLambda, "include-lambda", false, "Apply the image rules that read the Lambda digest file (private Lambda image by default)")
+	flag.StringVar(&imageRulesPath, "image-rules", "", "JSON file with an \"images\" list of {key, prefix, tag, index, input, optional} rules replacing the default registries (optional)")
+	flag.Var(&imageOverrides, "image", "Add or override one registry rule as key=prefix,tag,index|single, read from the docker digest file (repeatable)")
+	flag.StringVar(&ociLayoutDir, "oci-layout", "", "OCI image layout directory that replaces -digest-file for the images input, platforms included; every blob is verified against its digest (optional)")
+	flag.StringVar(&ociRepository, "oci-repository", "", "Repository for -oci-layout images whose ref.name annotation is only a tag (e.g., public.ecr.aws/conductorone/baton-example)")
 	flag.Parse()
 
 	if tag == "" {
@@ -44,12 +70,36 @@ func main() {
 		os.Exit(1)
 	}
 
+	rules := manifest.DefaultImageRules
+	if imageRulesPath != "" {
+		var err error
+		rules, err = manifest.LoadImageRules(imageRulesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "extract-images: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	rules = manifest.MergeImageRules(rules, imageOverrides...)
+	if err := checkInputRules(rules, includePublic, includeLambda); err != nil {
+		fmt.Fprintf(os.Stderr, "extract-images: error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Remove 'v' prefix if present to get version for matching image refs and file names
 	version := strings.TrimPrefix(tag, "v")
 
 	images := make(map[string]*pb.Image)
 
-	if includePublic {
+	switch {
+	case includePublic && ociLayoutDir != "":
+		// The layout describes itself, so no digest file is read
+		lines, err := layoutLines(ociLayoutDir, ociRepository)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "extract-images: ::error::%v\n", err)
+			os.Exit(1)
+		}
+		extractOrExit(lines, ociLayoutDir, rulesFor(rules, manifest.ImageInputImages), version, images)
+	case includePublic:
 		// Construct digest file path if not provided
 		if digestFile == "" {
 			if repoName == "" {
@@ -64,13 +114,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "extract-images: ::error::Digest file not found: %s\n", digestFile)
 			os.Exit(1)
 		}
-
-		foundECR := extractPublicImages(content, version, images)
-		if !foundECR {
-			fmt.Fprintf(os.Stderr, "extract-images: ::error::Could not find ECR public index image in %s\n", digestFile)
-			fmt.Fprintf(os.Stderr, "extract-images: Contents of digest file:\n%s\n", content)
-			os.Exit(1)
-		}
+		extractOrExit(parseDigestLines(content), digestFile, rulesFor(rules, manifest.ImageInputImages), version, images)
 	}
 
 	if includeLambda {
@@ -90,11 +134,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "extract-images: ::error::Lambda digest file not found: %s\n", lambdaDigestFile)
 			os.Exit(1)
 		}
-		if !extractLambdaImage(content, repoName, version, images) {
-			fmt.Fprintf(os.Stderr, "extract-images: ::error::Could not find Lambda arm64 image in %s\n", lambdaDigestFile)
-			fmt.Fprintf(os.Stderr, "extract-images: Contents of digest file:\n%s\n", content)
-			os.Exit(1)
-		}
+		extractOrExit(parseDigestLines(content), lambdaDigestFile, rulesFor(rules, manifest.ImageInputLambda), version, images)
 	}
 
 	imagesJSON, err := marshalImages(images)
@@ -112,64 +152,245 @@ func digestPath(assetDir, repoName, version string) string {
 	return fmt.Sprintf("%s/%s_%s_digests.txt", assetDir, repoName, version)
 }
 
-func extractPublicImages(content []byte, version string, images map[string]*pb.Image) bool {
-	var foundECR bool
-	for _, line := range parseDigestLines(content) {
-		// Only capture the multi-arch index images (tagged with just version, not version-arch)
-		if !strings.HasSuffix(line.ref, fmt.Sprintf(":%s", version)) {
-			continue
+// rulesFor returns the rules that read input.
+func rulesFor(rules []manifest.ImageRule, input string) []manifest.ImageRule {
+	var matched []manifest.ImageRule
+	for _, r := range rules {
+		if r.Input == input {
+			matched = append(matched, r)
 		}
+	}
+	return matched
+}
 
-		uri, ok := digestPinnedURI(line.ref, line.digest)
-		if !ok {
-			continue
+// checkInputRules returns an error when an enabled input has no rule reading
+// it, since its images would otherwise be dropped without failing the release.
+func checkInputRules(rules []manifest.ImageRule, includePublic, includeLambda bool) error {
+	inputs := []struct {
+		name    string
+		flag    string
+		enabled bool
+	}{
+		{manifest.ImageInputImages, "include-public", includePublic},
+		{manifest.ImageInputLambda, "include-lambda", includeLambda},
+	}
+	for _, in := range inputs {
+		if in.enabled && len(rulesFor(rules, in.name)) == 0 {
+			return fmt.Errorf("no image rule reads the %q input; add a rule with \"input\": %q or pass -%s=false", in.name, in.name, in.flag)
+		}
+	}
+	return nil
+}
+
+// extractOrExit applies rules to the images read from path (a digest file or
+// OCI layout), exiting when a rule fails or a required rule matches nothing.
+func extractOrExit(lines []digestLine, path string, rules []manifest.ImageRule, version string, images map[string]*pb.Image) {
+	missing, err := extractImages(lines, rules, version, images)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "extract-images: ::error::%s: %v\n", path, err)
+		os.Exit(1)
+	}
+	for _, r := range missing {
+		fmt.Fprintf(os.Stderr, "extract-images: ::error::Could not find %s image %s*:%s in %s\n", r.Key, r.Prefix, strings.ReplaceAll(r.Tag, "{version}", version), path)
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "extract-images: Images found in %s:\n", path)
+		for _, line := range lines {
+			fmt.Fprintf(os.Stderr, "%s  %s\n", line.digest, line.ref)
 		}
+		os.Exit(1)
+	}
+}
 
-		if strings.HasPrefix(line.ref, "public.ecr.aws/conductorone/") {
-			isIndex := true
-			images["ecrPublic"] = pb.Image_builder{
+// extractImages adds one image per rule that matches a digest line, keyed by
+// the rule's key. It returns the required rules that matched no line, and an
+// error when a rule matches two different images.
+func extractImages(lines []digestLine, rules []manifest.ImageRule, version string, images map[string]*pb.Image) ([]manifest.ImageRule, error) {
+	var missing []manifest.ImageRule
+	for _, rule := range rules {
+		var found *pb.Image
+		for _, line := range lines {
+			tag, ok := rule.MatchTag(line.ref, version)
+			if !ok {
+				continue
+			}
+			if found != nil {
+				if found.GetRef() != line.ref || found.GetDigest() != line.digest {
+					return nil, fmt.Errorf("image rule %s matches both %s and %s", rule.Key, found.GetRef(), line.ref)
+				}
+				continue
+			}
+			if line.fromLayout && line.isIndex != rule.Index {
+				return nil, fmt.Errorf("image rule %s expects index=%v but %s is index=%v", rule.Key, rule.Index, line.ref, line.isIndex)
+			}
+			uri, ok := digestPinnedURI(line.ref, line.digest)
+			if !ok {
+				continue
+			}
+			found = pb.Image_builder{
 				Ref:     &line.ref,
 				Digest:  &line.digest,
-				Tag:     &version,
+				Tag:     &tag,
 				Uri:     &uri,
-				IsIndex: &isIndex,
+				IsIndex: &rule.Index,
 			}.Build()
-			foundECR = true
+			switch {
+			case line.fromLayout:
+				found.SetPlatforms(line.platforms)
+			case rule.Index:
+				found.SetPlatforms(digestFilePlatforms(lines, line.ref))
+			}
+			if line.revision != "" {
+				found.SetRevision(line.revision)
+			}
+			if line.source != "" {
+				found.SetSource(line.source)
+			}
+		}
+		if found == nil {
+			if !rule.Optional {
+				missing = append(missing, rule)
+			}
+			continue
 		}
+		images[rule.Key] = found
 	}
-
-	return foundECR
+	return missing, nil
 }
 
-func extractLambdaImage(content []byte, repoName, version string, images map[string]*pb.Image) bool {
-	tag := fmt.Sprintf("%s-arm64", version)
-	for _, line := range parseDigestLines(content) {
-		if !strings.HasSuffix(line.ref, fmt.Sprintf(":%s", tag)) {
+// digestFilePlatforms returns the platforms of the index image ref from the
+// "<tag>-<platform>" lines written next to it (e.g., "0.1.2-arm64").
+func digestFilePlatforms(lines []digestLine, ref string) []*pb.ImagePlatform {
+	repo, tag, _ := manifest.SplitImageRef(ref)
+	var platforms []*pb.ImagePlatform
+	for _, line := range lines {
+		lineRepo, lineTag, ok := manifest.SplitImageRef(line.ref)
+		if !ok || lineRepo != repo {
 			continue
 		}
+		suffix, ok := strings.CutPrefix(lineTag, tag+"-")
+		if !ok {
+			continue
+		}
+		if p, ok := parsePlatformSuffix(suffix); ok {
+			platforms = append(platforms, imagePlatform(p, line.digest))
+		}
+	}
+	sortPlatforms(platforms)
+	return platforms
+}
+
+// platformArchitectures are the architectures recognized in tag suffixes.
+// Longer names come first so "arm64" is not read as "arm" with variant "64".
+var platformArchitectures = []string{"amd64", "arm64", "arm", "386", "ppc64le", "s390x", "riscv64"}
 
-		ref := line.ref
-		uri, ok := digestPinnedURI(ref, line.digest)
+// parsePlatformSuffix parses a tag suffix such as "amd64", "armv7" or
+// "linux-arm64". The os defaults to linux, the only os the images target.
+func parsePlatformSuffix(s string) (oci.Platform, bool) {
+	p := oci.Platform{OS: "linux"}
+	if goos, rest, ok := strings.Cut(s, "-"); ok {
+		p.OS, s = goos, rest
+	}
+	for _, arch := range platformArchitectures {
+		rest, ok := strings.CutPrefix(s, arch)
 		if !ok {
 			continue
 		}
-		isIndex := false
-		images[lambdaArm64ImageKey] = pb.Image_builder{
-			Ref:     &ref,
-			Digest:  &line.digest,
-			Tag:     &tag,
-			Uri:     &uri,
-			IsIndex: &isIndex,
-		}.Build()
-		return true
+		if rest != "" && (rest[0] != 'v' || len(rest) == 1 || strings.Trim(rest[1:], "0123456789") != "") {
+			return oci.Platform{}, false
+		}
+		p.Architecture, p.Variant = arch, rest
+		return p, true
+	}
+	return oci.Platform{}, false
+}
+
+// layoutLines reads every image in the OCI layout at dir, verifying each
+// blob, and names it by its ref.name annotation. A tag-only ref.name is
+// joined to the containerd image name's repository, or else to repository.
+func layoutLines(dir, repository string) ([]digestLine, error) {
+	layout, err := oci.OpenLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+	images, err := layout.Images()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	lines := make([]digestLine, 0, len(images))
+	for _, img := range images {
+		ref := layoutRef(img.Annotations, repository)
+		if ref == "" {
+			return nil, fmt.Errorf("%s: image %s has no %s annotation with a tag (set -oci-repository for tag-only names)", dir, img.Descriptor.Digest, oci.AnnotationRefName)
+		}
+		line := digestLine{
+			digest:     img.Descriptor.Digest,
+			ref:        ref,
+			fromLayout: true,
+			isIndex:    img.Descriptor.IsIndex(),
+			revision:   img.Revision(),
+			source:     img.Source(),
+		}
+		for _, p := range img.Platforms {
+			line.platforms = append(line.platforms, imagePlatform(p.Platform, p.Digest))
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func layoutRef(annotations map[string]string, repository string) string {
+	name := annotations[oci.AnnotationRefName]
+	if _, _, ok := manifest.SplitImageRef(name); ok && strings.Contains(name, "/") {
+		return name
+	}
+	if full := annotations[oci.AnnotationImageName]; full != "" {
+		if repo, _, ok := manifest.SplitImageRef(full); ok && name != "" {
+			return repo + ":" + name
+		}
+		return full
+	}
+	if name != "" && repository != "" {
+		return repository + ":" + name
+	}
+	return ""
+}
+
+func imagePlatform(p oci.Platform, digest string) *pb.ImagePlatform {
+	b := pb.ImagePlatform_builder{
+		Os:           &p.OS,
+		Architecture: &p.Architecture,
+		Digest:       &digest,
+	}
+	if p.Variant != "" {
+		b.Variant = &p.Variant
 	}
+	return b.Build()
+}
 
-	return false
+func sortPlatforms(platforms []*pb.ImagePlatform) {
+	sort.SliceStable(platforms, func(i, j int) bool {
+		a, b := platforms[i], platforms[j]
+		if a.GetOs() != b.GetOs() {
+			return a.GetOs() < b.GetOs()
+		}
+		if a.GetArchitecture() != b.GetArchitecture() {
+			return a.GetArchitecture() < b.GetArchitecture()
+		}
+		return a.GetVariant() < b.GetVariant()
+	})
 }
 
 type digestLine struct {
 	digest string
 	ref    string
+
+	// Set for images read from an OCI layout, which describe themselves.
+	fromLayout bool
+	isIndex    bool
+	platforms  []*pb.ImagePlatform
+	revision   string
+	source     string
 }
 
 func parseDigestLines(content []byte) []digestLine {
@@ -210,26 +431,14 @@ func digestPinnedURI(ref, digest string) (string, bool) {
 }
 
 func marshalImages(images map[string]*pb.Image) (string, error) {
-	opts := protojson.MarshalOptions{
-		Multiline:       true,
-		Indent:          "  ",
-		EmitUnpopulated: true,
-	}
-
 	imagesJSONParts := []string{"{"}
-	keys := make([]string, 0, len(images))
-	for key := range images {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-
 	first := true
-	for _, key := range keys {
+	for _, key := range manifest.SortedKeys(images) {
 		if !first {
 			imagesJSONParts = append(imagesJSONParts, ",")
 		}
 		first = false
-		imageJSON, err := opts.Marshal(images[key])
+		imageJSON, err := manifest.Marshal(images[key])
 		if err != nil {
 			return "", err
 		}
@@ -1,38 +1,49 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	"github.com/ConductorOne/github-workflows/internal/oci"
 	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
 )
 
 func TestExtractPublicImages(t *testing.T) {
 	images := make(map[string]*pb.Image)
-	foundECR := extractPublicImages([]byte(`
+	missing, err := extractImages(parseDigestLines([]byte(`
+bbb222  public.ecr.aws/conductorone/baton-example:0.1.2-arm64
 ccc333  public.ecr.aws/conductorone/baton-example:0.1.2
-`), "0.1.2", images)
+`)), rulesFor(manifest.DefaultImageRules, manifest.ImageInputImages), "0.1.2", images)
 
-	if !foundECR {
-		t.Fatal("ECR public image was not found")
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("ECR public image was not found: %v %v", missing, err)
 	}
 	if images["ecrPublic"].GetUri() != "public.ecr.aws/conductorone/baton-example@sha256:ccc333" {
 		t.Fatalf("ecrPublic uri = %q", images["ecrPublic"].GetUri())
 	}
+	if !images["ecrPublic"].GetIsIndex() {
+		t.Fatal("ecrPublic image should be marked as an index")
+	}
 }
 
 func TestExtractLambdaImagePreservesECRRef(t *testing.T) {
 	images := make(map[string]*pb.Image)
-	found := extractLambdaImage([]byte(`
+	missing, err := extractImages(parseDigestLines([]byte(`
 ddd444  168442440833.dkr.ecr.us-west-2.amazonaws.com/baton-example:0.1.2-arm64
-`), "baton-example", "0.1.2", images)
+`)), rulesFor(manifest.DefaultImageRules, manifest.ImageInputLambda), "0.1.2", images)
 
-	if !found {
-		t.Fatal("lambda image was not found")
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("lambda image was not found: %v %v", missing, err)
 	}
-	image := images[lambdaArm64ImageKey]
+	image := images["lambda-arm64"]
 	if image == nil {
-		t.Fatalf("missing %s image", lambdaArm64ImageKey)
+		t.Fatal("missing lambda-arm64 image")
 	}
 	if image.GetRef() != "168442440833.dkr.ecr.us-west-2.amazonaws.com/baton-example:0.1.2-arm64" {
 		t.Fatalf("lambda ref = %q", image.GetRef())
@@ -48,6 +59,191 @@ ddd444  168442440833.dkr.ecr.us-west-2.amazonaws.com/baton-example:0.1.2-arm64
 	}
 }
 
+func TestExtractImagesMultipleRegistries(t *testing.T) {
+	rules := manifest.MergeImageRules(rulesFor(manifest.DefaultImageRules, manifest.ImageInputImages),
+		manifest.ImageRule{Key: "ghcr", Prefix: "ghcr.io/conductorone/", Tag: "{version}", Index: true, Input: manifest.ImageInputImages},
+		manifest.ImageRule{Key: "private-amd64", Prefix: "registry.example.com/", Tag: "{version}-amd64", Input: manifest.ImageInputImages},
+		manifest.ImageRule{Key: "quay", Prefix: "quay.io/", Tag: "{version}", Input: manifest.ImageInputImages, Optional: true},
+	)
+	images := make(map[string]*pb.Image)
+	missing, err := extractImages(parseDigestLines([]byte(`
+aaa111  public.ecr.aws/conductorone/baton-example:0.1.2
+bbb222  ghcr.io/conductorone/baton-example:0.1.2
+ccc333  registry.example.com/baton-example:0.1.2-amd64
+ddd444  registry.example.com/baton-example:0.1.2
+`)), rules, "0.1.2", images)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("extractImages = %v, %v", missing, err)
+	}
+
+	for key, want := range map[string]struct {
+		uri     string
+		isIndex bool
+	}{
+		"ecrPublic":     {"public.ecr.aws/conductorone/baton-example@sha256:aaa111", true},
+		"ghcr":          {"ghcr.io/conductorone/baton-example@sha256:bbb222", true},
+		"private-amd64": {"registry.example.com/baton-example@sha256:ccc333", false},
+	} {
+		if images[key].GetUri() != want.uri || images[key].GetIsIndex() != want.isIndex {
+			t.Errorf("%s = %q is_index=%v, want %q is_index=%v", key, images[key].GetUri(), images[key].GetIsIndex(), want.uri, want.isIndex)
+		}
+	}
+	if _, ok := images["quay"]; ok {
+		t.Error("optional quay image should be absent")
+	}
+
+	// A rule whose tag pattern matches two images is ambiguous.
+	ambiguous := []manifest.ImageRule{{Key: "any", Tag: "{version}*", Input: manifest.ImageInputImages}}
+	if _, err := extractImages(parseDigestLines([]byte("aaa111  a.io/x:0.1.2\nbbb222  b.io/x:0.1.2-arm64\n")), ambiguous, "0.1.2", images); err == nil {
+		t.Fatal("expected an error for an ambiguous rule")
+	}
+	missing, err = extractImages(nil, rules, "0.1.2", images)
+	if err != nil || len(missing) != 3 {
+		t.Fatalf("missing = %v, %v, want the three required rules", missing, err)
+	}
+}
+
+func TestCheckInputRulesRequiresARulePerEnabledInput(t *testing.T) {
+	if err := checkInputRules(manifest.DefaultImageRules, true, true); err != nil {
+		t.Fatalf("default rules: %v", err)
+	}
+	publicOnly := rulesFor(manifest.DefaultImageRules, manifest.ImageInputImages)
+	if err := checkInputRules(publicOnly, true, false); err != nil {
+		t.Fatalf("lambda disabled: %v", err)
+	}
+	err := checkInputRules(publicOnly, true, true)
+	if err == nil || !strings.Contains(err.Error(), `"lambda"`) || !strings.Contains(err.Error(), "-include-lambda=false") {
+		t.Fatalf("err = %v, want the lambda input named", err)
+	}
+	if err := checkInputRules(rulesFor(manifest.DefaultImageRules, manifest.ImageInputLambda), true, true); err == nil {
+		t.Fatal("expected an error for the images input without a rule")
+	}
+}
+
+func TestExtractImagesRecordsPlatforms(t *testing.T) {
+	images := make(map[string]*pb.Image)
+	missing, err := extractImages(parseDigestLines([]byte(`
+aaa111  public.ecr.aws/conductorone/baton-example:0.1.2-arm64
+bbb222  public.ecr.aws/conductorone/baton-example:0.1.2-amd64
+ccc333  public.ecr.aws/conductorone/baton-example:0.1.2
+ddd444  public.ecr.aws/conductorone/baton-example:0.1.2-armv7
+eee555  public.ecr.aws/conductorone/baton-example:0.1.2-rc1
+fff666  ghcr.io/conductorone/baton-example:0.1.2-386
+`)), rulesFor(manifest.DefaultImageRules, manifest.ImageInputImages), "0.1.2", images)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("extractImages = %v, %v", missing, err)
+	}
+
+	var got []string
+	for _, p := range images["ecrPublic"].GetPlatforms() {
+		got = append(got, p.GetOs()+"/"+p.GetArchitecture()+"/"+p.GetVariant()+"="+p.GetDigest())
+	}
+	want := "linux/amd64/=sha256:bbb222 linux/arm/v7=sha256:ddd444 linux/arm64/=sha256:aaa111"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("platforms = %s\nwant        %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestParsePlatformSuffix(t *testing.T) {
+	for suffix, want := range map[string]string{
+		"amd64":         "linux/amd64",
+		"arm64v8":       "linux/arm64/v8",
+		"armv6":         "linux/arm/v6",
+		"windows-amd64": "windows/amd64",
+		"rc1":           "",
+		"armhf":         "",
+		"arm64v":        "",
+	} {
+		p, ok := parsePlatformSuffix(suffix)
+		got := ""
+		if ok {
+			got = p.String()
+		}
+		if got != want {
+			t.Errorf("parsePlatformSuffix(%q) = %q, want %q", suffix, got, want)
+		}
+	}
+}
+
+func TestExtractImagesFromLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blob := func(mediaType string, v any) oci.Descriptor {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		writeFile("blobs/sha256/"+hex.EncodeToString(sum[:]), data)
+		return oci.Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(data))}
+	}
+	image := func(arch string) oci.Descriptor {
+		config := blob("application/vnd.oci.image.config.v1+json", map[string]any{
+			"os": "linux", "architecture": arch,
+			"config": map[string]any{"Labels": map[string]string{oci.AnnotationSource: "https://github.com/ConductorOne/baton-example"}},
+		})
+		d := blob(oci.MediaTypeImageManifest, oci.Manifest{SchemaVersion: 2, MediaType: oci.MediaTypeImageManifest, Config: config})
+		d.Platform = &oci.Platform{OS: "linux", Architecture: arch}
+		return d
+	}
+	arm64, amd64 := image("arm64"), image("amd64")
+	index := blob(oci.MediaTypeImageIndex, oci.Index{SchemaVersion: 2, MediaType: oci.MediaTypeImageIndex, Manifests: []oci.Descriptor{arm64, amd64}})
+	index.Annotations = map[string]string{oci.AnnotationRefName: "0.1.2", oci.AnnotationRevision: "abc123"}
+	single := amd64
+	single.Platform = nil
+	single.Annotations = map[string]string{oci.AnnotationRefName: "ghcr.io/conductorone/baton-example:0.1.2-amd64"}
+	writeFile("oci-layout", []byte(`{"imageLayoutVersion": "1.0.0"}`))
+	data, err := json.Marshal(oci.Index{SchemaVersion: 2, Manifests: []oci.Descriptor{index, single}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile("index.json", data)
+
+	if _, err := layoutLines(dir, ""); err == nil {
+		t.Fatal("expected an error for a tag-only ref.name without -oci-repository")
+	}
+	lines, err := layoutLines(dir, "public.ecr.aws/conductorone/baton-example")
+	if err != nil {
+		t.Fatalf("layoutLines: %v", err)
+	}
+	rules := manifest.MergeImageRules(rulesFor(manifest.DefaultImageRules, manifest.ImageInputImages),
+		manifest.ImageRule{Key: "ghcr-amd64", Prefix: "ghcr.io/conductorone/", Tag: "{version}-amd64", Input: manifest.ImageInputImages})
+	images := make(map[string]*pb.Image)
+	missing, err := extractImages(lines, rules, "0.1.2", images)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("extractImages = %v, %v", missing, err)
+	}
+
+	ecr := images["ecrPublic"]
+	if ecr.GetUri() != "public.ecr.aws/conductorone/baton-example@"+index.Digest || !ecr.GetIsIndex() {
+		t.Fatalf("ecrPublic = %v", ecr)
+	}
+	if ecr.GetRevision() != "abc123" || ecr.GetSource() != "https://github.com/ConductorOne/baton-example" {
+		t.Fatalf("revision, source = %q, %q", ecr.GetRevision(), ecr.GetSource())
+	}
+	platforms := ecr.GetPlatforms()
+	if len(platforms) != 2 || platforms[0].GetArchitecture() != "amd64" || platforms[0].GetDigest() != amd64.Digest {
+		t.Fatalf("platforms = %v", platforms)
+	}
+	if ghcr := images["ghcr-amd64"]; ghcr.GetDigest() != amd64.Digest || ghcr.GetIsIndex() || len(ghcr.GetPlatforms()) != 0 {
+		t.Fatalf("ghcr-amd64 = %v", ghcr)
+	}
+
+	// A layout says what each image is, so a rule expecting an index fails on a single manifest.
+	wrongKind := []manifest.ImageRule{{Key: "ghcr", Prefix: "ghcr.io/conductorone/", Tag: "{version}-amd64", Index: true, Input: manifest.ImageInputImages}}
+	if _, err := extractImages(lines, wrongKind, "0.1.2", images); err == nil {
+		t.Fatal("expected an error for an index rule matching a single-platform image")
+	}
+}
+
 func TestMarshalImagesSortsKeys(t *testing.T) {
 	isIndex := true
 	images := map[string]*pb.Image{
@@ -1,36 +1,73 @@
 package main
 
 import (
-	"bufio"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/ConductorOne/github-workflows/internal/manifest"
 	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
 )
 
+// assetPatternFlags collects repeated -asset flags.
+type assetPatternFlags []manifest.AssetPattern
+
+func (f *assetPatternFlags) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, p := range *f {
+		parts = append(parts, p.Key+"="+p.Pattern)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *assetPatternFlags) Set(value string) error {
+	p, err := manifest.ParseAssetPattern(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, p)
+	return nil
+}
+
 func main() {
 	var (
-		assetDir string
-		repoName string
-		orgName  string
-		tag      string
-		baseURL  string
+		assetDir          string
+		repoName          string
+		orgName           string
+		tag               string
+		baseURL           string
+		assetPatternsPath string
+		assetOverrides    assetPatternFlags
+		failOnUnknown     bool
+		strict            bool
+		verifyChecksums   bool
+		readBuildInfo     bool
+		commitSha         string
+		releasedAt        string
+		sourceDir         string
 	)
 	flag.StringVar(&assetDir, "asset-dir", ".", "Directory containing distribution artifacts")
 	flag.StringVar(&repoName, "repo-name", "", "Repository name")
 	flag.StringVar(&orgName, "org-name", "", "Organization name")
 	flag.StringVar(&tag, "tag", "", "Release tag (e.g., v0.0.8)")
 	flag.StringVar(&baseURL, "base-url", "", "Base URL for artifact downloads")
+	flag.StringVar(&assetPatternsPath, "asset-patterns", "", "JSON file with an \"assets\" list of {key, pattern, mediaType} entries replacing the default platform patterns (optional)")
+	flag.Var(&assetOverrides, "asset", "Add or override one platform pattern as key=pattern[,mediaType] (repeatable)")
+	flag.BoolVar(&failOnUnknown, "fail-on-unknown", false, "Fail when the asset dir contains archives that no pattern matches")
+	flag.BoolVar(&strict, "strict", false, "Fail instead of warn when a pattern matches several files or a matched file does not carry the tag version")
+	flag.BoolVar(&verifyChecksums, "verify-checksums", false, "Rehash every matched artifact and fail if it differs from checksums.txt or a checksums entry has no file")
+	flag.BoolVar(&readBuildInfo, "build-info", true, "Record the Go build info of the binary inside each zip/tar.gz archive on its asset")
+	flag.StringVar(&commitSha, "commit-sha", "", "Release commit; when set, every archived binary's vcs.revision must equal it and vcs.modified must be false (optional)")
+	flag.StringVar(&releasedAt, "released-at", "", "Release timestamp in RFC 3339 format (optional, overrides SOURCE_DATE_EPOCH and -source-dir)")
+	flag.StringVar(&sourceDir, "source-dir", "", "Git checkout of the released repository; released_at defaults to the tag's commit time (optional)")
 	flag.Parse()
 
 	if repoName == "" || orgName == "" || tag == "" || baseURL == "" {
@@ -38,135 +75,156 @@ func main() {
 		os.Exit(1)
 	}
 
-	now := time.Now().UTC()
+	patterns := manifest.DefaultAssetPatterns
+	if assetPatternsPath != "" {
+		var err error
+		patterns, err = manifest.LoadAssetPatterns(assetPatternsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	patterns = manifest.MergeAssetPatterns(patterns, assetOverrides...)
+
+	// released_at must not depend on when the job ran, or rebuilding the same
+	// release would produce a different (differently signed) manifest.
+	releaseTime, source, err := resolveReleaseTime(releasedAt, os.Getenv("SOURCE_DATE_EPOCH"), sourceDir, tag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate-manifest: error: %v\n", err)
+		os.Exit(1)
+	}
+	if source == "" {
+		fmt.Fprintf(os.Stderr, "generate-manifest: warning: released_at uses the current time; set -released-at, SOURCE_DATE_EPOCH or -source-dir for reproducible output\n")
+		releaseTime = time.Now().UTC()
+	} else {
+		fmt.Fprintf(os.Stderr, "✅ released_at %s (from %s)\n", releaseTime.Format(time.RFC3339), source)
+	}
 	assets := make(map[string]*pb.Asset)
 
-	// Asset patterns: platform -> (pattern, mediaType)
-	assetPatterns := map[string]struct {
-		pattern   string
-		mediaType string
-	}{
-		"darwin-arm64":  {"*darwin-arm64.zip", "application/zip"},
-		"darwin-amd64":  {"*darwin-amd64.zip", "application/zip"},
-		"linux-arm64":   {"*linux-arm64.tar.gz", "application/gzip"},
-		"linux-amd64":   {"*linux-amd64.tar.gz", "application/gzip"},
-		"windows-amd64": {"*windows-amd64.zip", "application/zip"},
-		"checksums":     {"*checksums.txt", "text/plain"},
+	// Problems with glob matches are warnings by default and fatal in strict mode.
+	// All of them are reported before exiting so one run shows every stale file.
+	var matchProblems int
+	reportMatchProblems := func(problems []string) {
+		level := "warning"
+		if strict {
+			level = "error"
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "generate-manifest: %s: %s\n", level, p)
+		}
+		matchProblems += len(problems)
 	}
 
 	// Parse checksums file first to get SHA256 hashes from goreleaser
-	checksumsMap, err := parseChecksumsFile(assetDir)
+	checksumsFiles, err := manifest.FindChecksumsFiles(assetDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate-manifest: error: %v\n", err)
+		os.Exit(1)
+	}
+	reportMatchProblems(checkMatches(manifest.ChecksumsPattern, checksumsFiles, tag))
+	checksumsMap, err := manifest.ReadChecksumsFile(filepath.Join(assetDir, checksumsFiles[0]))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "generate-manifest: error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Find and add assets
-	for key, pattern := range assetPatterns {
-		matches, err := filepath.Glob(filepath.Join(assetDir, pattern.pattern))
+	var matched []string
+	for _, pattern := range patterns {
+		matches, err := manifest.Glob(assetDir, pattern.Pattern)
 		if err != nil || len(matches) == 0 {
 			continue
 		}
-		filename := filepath.Base(matches[0])
-
-		// Get file size
-		info, err := os.Stat(filepath.Join(assetDir, filename))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "generate-manifest: warning: failed to stat %s: %v\n", filename, err)
-			continue
+		if pattern.Pattern != manifest.ChecksumsPattern {
+			reportMatchProblems(checkMatches(pattern.Pattern, matches, tag))
 		}
-		size := info.Size()
+		filename := matches[0]
 
 		// Get SHA256 from checksums file (required - must match goreleaser output)
 		sha256Hash, ok := checksumsMap[filename]
-		if !ok {
-			// Skip checksums file itself (it won't be in its own checksums file)
-			if key == "checksums" {
-				// Calculate hash for the checksums file itself
-				calculatedHash, err := calculateFileSHA256(filepath.Join(assetDir, filename))
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "generate-manifest: error: failed to calculate hash for checksums file %s: %v\n", filename, err)
-					os.Exit(1)
-				}
-				sha256Hash = calculatedHash
-			} else {
-				fmt.Fprintf(os.Stderr, "generate-manifest: error: SHA256 hash not found in checksums file for %s\n", filename)
-				fmt.Fprintf(os.Stderr, "generate-manifest: error: all hashes must come from goreleaser checksums file\n")
+		// The checksums file itself won't be in its own checksums file; leaving the hash
+		// empty makes NewAsset calculate it. With -verify-checksums a missing entry is
+		// reported below together with any mismatches.
+		if !ok && pattern.Key != "checksums" && !verifyChecksums {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: SHA256 hash not found in checksums file for %s\n", filename)
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: all hashes must come from goreleaser checksums file\n")
+			os.Exit(1)
+		}
+		if pattern.Key != "checksums" {
+			matched = append(matched, filename)
+		}
+
+		asset, err := manifest.NewAsset(assetDir, filename, manifest.AssetOptions{
+			MediaType:    pattern.MediaType,
+			SHA256:       sha256Hash,
+			BaseURL:      baseURL,
+			AssumeSigned: true,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: processing %s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		if readBuildInfo && manifest.IsArchive(filename) {
+			if err := recordBuildInfo(asset, filepath.Join(assetDir, filename), commitSha); err != nil {
+				fmt.Fprintf(os.Stderr, "generate-manifest: error: %v\n", err)
 				os.Exit(1)
 			}
 		}
+		assets[pattern.Key] = asset
+	}
 
-		href := fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), filename)
-		builder := pb.Asset_builder{
-			Filename:        &filename,
-			MediaType:       &pattern.mediaType,
-			SizeBytes:       &size,
-			Sha256:          &sha256Hash,
-			Href:            &href,
-			SignatureHref:   stringPtr(href + ".sig"),
-			CertificateHref: stringPtr(href + ".cert"),
+	if verifyChecksums {
+		problems, err := manifest.VerifyChecksums(assetDir, checksumsMap, matched)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: verifying checksums: %v\n", err)
+			os.Exit(1)
 		}
-
-		// Check for provenance attestation bundle
-		var attestations []*pb.AttestationDescriptor
-		provenancePath := filepath.Join(assetDir, filename+".provenance.sigstore.json")
-		if _, err := os.Stat(provenancePath); err == nil {
-			attestationType := "https://in-toto.io/Statement/v1"
-			predicateType := "https://slsa.dev/provenance/v1"
-			bundleHref := fmt.Sprintf("%s/%s.provenance.sigstore.json", strings.TrimSuffix(baseURL, "/"), filename)
-			attestations = append(attestations, pb.AttestationDescriptor_builder{
-				AttestationType: &attestationType,
-				PredicateType:   &predicateType,
-				BundleHref:      &bundleHref,
-			}.Build())
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: %s\n", p)
 		}
-
-		// Check for SBOM attestation bundle
-		sbomBundlePath := filepath.Join(assetDir, filename+".sbom.sigstore.json")
-		if _, err := os.Stat(sbomBundlePath); err == nil {
-			attestationType := "https://in-toto.io/Statement/v1"
-			predicateType := "https://spdx.dev/Document"
-			bundleHref := fmt.Sprintf("%s/%s.sbom.sigstore.json", strings.TrimSuffix(baseURL, "/"), filename)
-			attestations = append(attestations, pb.AttestationDescriptor_builder{
-				AttestationType: &attestationType,
-				PredicateType:   &predicateType,
-				BundleHref:      &bundleHref,
-			}.Build())
+		if len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "generate-manifest: error: %d artifacts do not match %s\n", len(problems), checksumsFiles[0])
+			os.Exit(1)
 		}
+		fmt.Fprintf(os.Stderr, "✅ Verified %d artifacts against %s\n", len(matched), checksumsFiles[0])
+	}
 
-		if len(attestations) > 0 {
-			builder.Attestations = attestations
-		}
+	if matchProblems > 0 && strict {
+		fmt.Fprintf(os.Stderr, "generate-manifest: error: %d problems with matched artifacts in %s (remove stale files from previous builds)\n", matchProblems, assetDir)
+		os.Exit(1)
+	}
 
-		asset := builder.Build()
-		assets[key] = asset
+	// Report archives that would otherwise be silently left out of the manifest
+	unmatched, err := manifest.UnmatchedArtifacts(assetDir, patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate-manifest: error: listing asset dir: %v\n", err)
+		os.Exit(1)
+	}
+	for _, filename := range unmatched {
+		fmt.Fprintf(os.Stderr, "generate-manifest: warning: %s does not match any asset pattern and is not in the manifest\n", filename)
+	}
+	if len(unmatched) > 0 && failOnUnknown {
+		fmt.Fprintf(os.Stderr, "generate-manifest: error: %d unknown artifacts in %s (add a pattern with -asset or -asset-patterns)\n", len(unmatched), assetDir)
+		os.Exit(1)
 	}
 
 	// Build manifest with builder pattern
-	version := "2"
-	baseURLTrimmed := strings.TrimSuffix(baseURL, "/")
-	signatureHref := fmt.Sprintf("%s/manifest.json.sig", baseURLTrimmed)
-	certificateHref := fmt.Sprintf("%s/manifest.json.cert", baseURLTrimmed)
+	version := manifest.SchemaVersion
+	signatureHref := manifest.JoinURL(baseURL, "manifest.json.sig")
+	certificateHref := manifest.JoinURL(baseURL, "manifest.json.cert")
 
-	manifest := pb.Manifest_builder{
+	m := pb.Manifest_builder{
 		Version:         &version,
 		Name:            &repoName,
 		Org:             &orgName,
 		Semver:          &tag,
-		ReleasedAt:      timestamppb.New(now),
+		ReleasedAt:      timestamppb.New(releaseTime),
 		Assets:          assets,
 		SignatureHref:   &signatureHref,
 		CertificateHref: &certificateHref,
 	}.Build()
 
-	// Marshal to JSON
-	// Marshal options with frontend consumption in mind. Ensures all fields are present for predictable structure.
-	opts := protojson.MarshalOptions{
-		Multiline:       true,
-		Indent:          "  ",
-		EmitUnpopulated: true,
-	}
-	jsonBytes, err := opts.Marshal(manifest)
+	jsonBytes, err := manifest.Marshal(m)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "generate-manifest: error: marshaling manifest: %v\n", err)
 		os.Exit(1)
@@ -177,66 +235,73 @@ func main() {
 	fmt.Fprintln(os.Stderr, "✅ Generated manifest")
 }
 
-// parseChecksumsFile parses the goreleaser checksums file and returns a map of filename -> SHA256 hash.
-// The checksums file format is: <sha256>  <filename>
-// Returns an error if the checksums file cannot be found or read.
-func parseChecksumsFile(assetDir string) (map[string]string, error) {
-	// Find checksums file
-	matches, err := filepath.Glob(filepath.Join(assetDir, "*checksums.txt"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to search for checksums file: %w", err)
-	}
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("checksums file not found in %s (expected pattern: *checksums.txt)", assetDir)
-	}
-
-	checksumsFile := matches[0]
-	file, err := os.Open(checksumsFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open checksums file %s: %w", checksumsFile, err)
+// checkMatches returns a description of each problem with the files matched by pattern:
+// more than one match (the manifest would point at whichever sorts first), or a match
+// whose name does not carry the release tag (a leftover from a previous build).
+func checkMatches(pattern string, matches []string, tag string) []string {
+	var problems []string
+	if len(matches) > 1 {
+		problems = append(problems, fmt.Sprintf("pattern %s matched %d files: %s", pattern, len(matches), strings.Join(matches, ", ")))
 	}
-	defer file.Close()
-
-	checksumsMap := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-		// Format: <sha256>  <filename> or <sha256>  *<filename>
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			hash := parts[0]
-			filename := strings.TrimPrefix(parts[1], "*")
-			checksumsMap[filename] = hash
+	for _, filename := range matches {
+		if !manifest.FilenameHasVersion(filename, tag) {
+			problems = append(problems, fmt.Sprintf("%s does not contain release version %s", filename, tag))
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read checksums file %s: %w", checksumsFile, err)
-	}
-
-	return checksumsMap, nil
+	return problems
 }
 
-// calculateFileSHA256 calculates the SHA256 hash of a file.
-func calculateFileSHA256(filepath string) (string, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return "", err
+// recordBuildInfo sets the build info of the Go binary in the archive at path
+// on asset. With commitSha set the binary must have been built from that
+// commit, and an archive without a Go binary is an error rather than a warning.
+func recordBuildInfo(asset *pb.Asset, path, commitSha string) error {
+	info, binary, err := manifest.ReadArchiveBuildInfo(path)
+	switch {
+	case errors.Is(err, manifest.ErrNoGoBinary) && commitSha != "":
+		return fmt.Errorf("%s contains no Go binary, so it cannot be verified against commit %s", asset.GetFilename(), commitSha)
+	case errors.Is(err, manifest.ErrNoGoBinary):
+		fmt.Fprintf(os.Stderr, "generate-manifest: warning: %s contains no Go binary; build info not recorded\n", asset.GetFilename())
+		return nil
+	case err != nil:
+		return fmt.Errorf("reading build info: %w", err)
 	}
-	defer file.Close()
-
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	manifest.SetBuildInfo(asset, info)
+	fmt.Fprintf(os.Stderr, "✅ Read build info from %s in %s (%s)\n", binary, asset.GetFilename(), info.GoVersion)
+	if commitSha == "" {
+		return nil
 	}
-
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return manifest.VerifyBuildRevision(asset, commitSha)
 }
 
-// stringPtr returns a pointer to the given string value.
-func stringPtr(s string) *string {
-	return &s
+// resolveReleaseTime returns released_at from, in order: the -released-at
+// flag, SOURCE_DATE_EPOCH, or the commit time of tag in the git checkout at
+// sourceDir. The second result names the source used, and is empty when none
+// of them is set.
+func resolveReleaseTime(flagValue, sourceDateEpoch, sourceDir, tag string) (time.Time, string, error) {
+	switch {
+	case flagValue != "":
+		t, err := time.Parse(time.RFC3339, flagValue)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid -released-at %q: %w", flagValue, err)
+		}
+		return t.UTC(), "-released-at", nil
+	case sourceDateEpoch != "":
+		secs, err := strconv.ParseInt(sourceDateEpoch, 10, 64)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", sourceDateEpoch, err)
+		}
+		return time.Unix(secs, 0).UTC(), "SOURCE_DATE_EPOCH", nil
+	case sourceDir != "":
+		out, err := exec.Command("git", "-C", sourceDir, "log", "-1", "--format=%ct", "refs/tags/"+tag).Output()
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("reading commit time of %s in %s: %w", tag, sourceDir, err)
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("parsing commit time of %s: %w", tag, err)
+		}
+		return time.Unix(secs, 0).UTC(), tag + " commit time", nil
+	default:
+		return time.Time{}, "", nil
+	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func TestCheckMatchesSingleCurrentFile(t *testing.T) {
+	if problems := checkMatches("*linux-amd64.tar.gz", []string{"baton-example-v0.1.2-linux-amd64.tar.gz"}, "v0.1.2"); len(problems) != 0 {
+		t.Fatalf("problems = %v, want none", problems)
+	}
+}
+
+func TestCheckMatchesReportsAmbiguousAndStaleFiles(t *testing.T) {
+	problems := checkMatches("*linux-amd64.tar.gz", []string{
+		"baton-example-v0.1.1-linux-amd64.tar.gz",
+		"baton-example-v0.1.2-linux-amd64.tar.gz",
+	}, "v0.1.2")
+	if len(problems) != 2 {
+		t.Fatalf("problems = %v, want ambiguity and stale version", problems)
+	}
+	if !strings.Contains(problems[0], "matched 2 files") {
+		t.Fatalf("first problem = %q, want ambiguity", problems[0])
+	}
+	if !strings.Contains(problems[1], "v0.1.1-linux-amd64") {
+		t.Fatalf("second problem = %q, want stale file", problems[1])
+	}
+}
+
+func TestResolveReleaseTimePrecedence(t *testing.T) {
+	got, source, err := resolveReleaseTime("2025-01-02T03:04:05+01:00", "1700000000", "", "v0.1.2")
+	if err != nil || source != "-released-at" || !got.Equal(time.Date(2025, 1, 2, 2, 4, 5, 0, time.UTC)) {
+		t.Fatalf("flag: %v %q %v", got, source, err)
+	}
+	got, source, err = resolveReleaseTime("", "1700000000", "", "v0.1.2")
+	if err != nil || source != "SOURCE_DATE_EPOCH" || got.Unix() != 1700000000 {
+		t.Fatalf("SOURCE_DATE_EPOCH: %v %q %v", got, source, err)
+	}
+	if _, source, err = resolveReleaseTime("", "", "", "v0.1.2"); err != nil || source != "" {
+		t.Fatalf("no source: %q %v", source, err)
+	}
+	if _, _, err = resolveReleaseTime("", "yesterday", "", "v0.1.2"); err == nil {
+		t.Fatal("expected an error for an invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestResolveReleaseTimeFromTagCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_COMMITTER_DATE=2024-05-06T07:08:09Z",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "release")
+	git("tag", "-a", "v0.1.2", "-m", "v0.1.2")
+
+	got, source, err := resolveReleaseTime("", "", dir, "v0.1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != "v0.1.2 commit time" || !got.Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)) {
+		t.Fatalf("got %v from %q", got, source)
+	}
+}
+
+func TestRecordBuildInfoWithoutGoBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baton-example-v0.1.2-linux-amd64.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("README.md")
+	w.Write([]byte("# baton-example\n"))
+	zw.Close()
+	f.Close()
+
+	filename := filepath.Base(path)
+	asset := pb.Asset_builder{Filename: &filename}.Build()
+	if err := recordBuildInfo(asset, path, ""); err != nil {
+		t.Fatalf("without -commit-sha: %v, want only a warning", err)
+	}
+	err = recordBuildInfo(asset, path, "0123456789abcdef0123456789abcdef01234567")
+	if err == nil || !strings.Contains(err.Error(), "contains no Go binary") {
+		t.Fatalf("err = %v, want a single no Go binary error", err)
+	}
+}
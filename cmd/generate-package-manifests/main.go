@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// errMissingAssets is returned by a renderer when the manifest has none of the
+// assets its package format installs.
+var errMissingAssets = errors.New("manifest has no assets for this format")
+
+// renderer produces the files for one package format, keyed by path relative
+// to the output directory.
+type renderer func(p *packageInfo, m *pb.Manifest) (map[string][]byte, error)
+
+var renderers = map[string]renderer{
+	"homebrew": renderHomebrew,
+	"scoop":    renderScoop,
+	"winget":   renderWinget,
+}
+
+func main() {
+	var (
+		manifestPath string
+		outputDir    string
+		formats      string
+		description  string
+		homepage     string
+		license      string
+		publisher    string
+		binary       string
+	)
+	flag.StringVar(&manifestPath, "manifest", "", "Path to the merged manifest.json (required)")
+	flag.StringVar(&outputDir, "output-dir", "", "Directory to write package manifests into (required)")
+	flag.StringVar(&formats, "formats", "homebrew,scoop,winget", "Comma-separated package formats to render")
+	flag.StringVar(&description, "description", "", "Short package description (optional, defaults to \"{name} by {org}\")")
+	flag.StringVar(&homepage, "homepage", "", "Package homepage (optional, defaults to the GitHub repository)")
+	flag.StringVar(&license, "license", "Apache-2.0", "SPDX license identifier")
+	flag.StringVar(&publisher, "publisher", "", "winget publisher and identifier prefix (optional, defaults to the org)")
+	flag.StringVar(&binary, "binary", "", "Name of the binary inside the archives (optional, defaults to the repository name)")
+	flag.Parse()
+
+	if manifestPath == "" || outputDir == "" {
+		fmt.Fprintf(os.Stderr, "generate-package-manifests: error: manifest and output-dir are required\n")
+		os.Exit(1)
+	}
+
+	m, err := manifest.ReadManifest(manifestPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate-package-manifests: error: %v\n", err)
+		os.Exit(1)
+	}
+	p, err := newPackageInfo(m, description, homepage, license, publisher, binary)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate-package-manifests: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	written := 0
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		render, ok := renderers[format]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "generate-package-manifests: error: unknown format %q\n", format)
+			os.Exit(1)
+		}
+		files, err := render(p, m)
+		if errors.Is(err, errMissingAssets) {
+			fmt.Fprintf(os.Stderr, "generate-package-manifests: warning: skipping %s: %v\n", format, err)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generate-package-manifests: error: rendering %s: %v\n", format, err)
+			os.Exit(1)
+		}
+		if err := writeFiles(outputDir, files); err != nil {
+			fmt.Fprintf(os.Stderr, "generate-package-manifests: error: %v\n", err)
+			os.Exit(1)
+		}
+		written++
+	}
+	if written == 0 {
+		fmt.Fprintf(os.Stderr, "generate-package-manifests: error: no package manifests rendered\n")
+		os.Exit(1)
+	}
+}
+
+// packageInfo holds the metadata shared by every package format.
+type packageInfo struct {
+	Name string
+	Org  string
+	// Version is the semver without its "v" prefix, as package managers expect.
+	Version     string
+	Description string
+	Homepage    string
+	License     string
+	Publisher   string
+	Binary      string
+	// ReleaseDate is released_at as YYYY-MM-DD, or empty when unset.
+	ReleaseDate string
+}
+
+func newPackageInfo(m *pb.Manifest, description, homepage, license, publisher, binary string) (*packageInfo, error) {
+	if m.GetName() == "" || m.GetOrg() == "" || m.GetSemver() == "" {
+		return nil, errors.New("manifest must have name, org and semver")
+	}
+	p := &packageInfo{
+		Name:        m.GetName(),
+		Org:         m.GetOrg(),
+		Version:     strings.TrimPrefix(m.GetSemver(), "v"),
+		Description: description,
+		Homepage:    homepage,
+		License:     license,
+		Publisher:   publisher,
+		Binary:      binary,
+	}
+	if p.Description == "" {
+		p.Description = fmt.Sprintf("%s by %s", p.Name, p.Org)
+	}
+	if p.Homepage == "" {
+		p.Homepage = fmt.Sprintf("https://github.com/%s/%s", p.Org, p.Name)
+	}
+	if p.Publisher == "" {
+		p.Publisher = p.Org
+	}
+	if p.Binary == "" {
+		p.Binary = p.Name
+	}
+	if m.HasReleasedAt() {
+		p.ReleaseDate = m.GetReleasedAt().AsTime().UTC().Format("2006-01-02")
+	}
+	return p, nil
+}
+
+// writeFiles writes files under dir in sorted order, creating parent directories.
+func writeFiles(dir string, files map[string][]byte) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(full), err)
+		}
+		if err := os.WriteFile(full, files[path], 0o600); err != nil {
+			return fmt.Errorf("writing %s: %w", full, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Wrote %s\n", full)
+	}
+	return nil
+}
+
+// --- Homebrew ---
+
+// brewOS groups the archives for one Homebrew OS block (on_macos, on_linux).
+type brewOS struct {
+	OS    string
+	Archs []brewArch
+}
+
+// brewArch is one on_arm/on_intel block.
+type brewArch struct {
+	Arch   string
+	URL    string
+	SHA256 string
+}
+
+var homebrewTemplate = template.Must(template.New("formula").Funcs(template.FuncMap{"ruby": rubyString}).Parse(`# typed: false
+# frozen_string_literal: true
+
+# Generated by generate-package-manifests from the {{ .Info.Name }} {{ .Info.Version }} release manifest. DO NOT EDIT.
+class {{ .Class }} < Formula
+  desc {{ ruby .Info.Description }}
+  homepage {{ ruby .Info.Homepage }}
+  version {{ ruby .Info.Version }}
+  license {{ ruby .Info.License }}
+{{ range .OSes }}
+  on_{{ .OS }} do
+{{- range .Archs }}
+    on_{{ .Arch }} do
+      url {{ ruby .URL }}
+      sha256 {{ ruby .SHA256 }}
+    end
+{{- end }}
+  end
+{{ end }}
+  def install
+    bin.install {{ ruby .Info.Binary }}
+  end
+
+  test do
+    system "#{bin}/{{ .Info.Binary }}", "--version"
+  end
+end
+`))
+
+// renderHomebrew renders Formula/{name}.rb from the darwin and linux archives.
+func renderHomebrew(p *packageInfo, m *pb.Manifest) (map[string][]byte, error) {
+	var oses []brewOS
+	for _, platform := range []struct{ goos, brew string }{{"darwin", "macos"}, {"linux", "linux"}} {
+		block := brewOS{OS: platform.brew}
+		for _, arch := range []struct{ goarch, brew string }{{"arm64", "arm"}, {"amd64", "intel"}} {
+			asset, ok := m.GetAssets()[platform.goos+"-"+arch.goarch]
+			if !ok {
+				continue
+			}
+			block.Archs = append(block.Archs, brewArch{Arch: arch.brew, URL: asset.GetHref(), SHA256: strings.ToLower(asset.GetSha256())})
+		}
+		if len(block.Archs) > 0 {
+			oses = append(oses, block)
+		}
+	}
+	if len(oses) == 0 {
+		return nil, fmt.Errorf("%w: need darwin-arm64, darwin-amd64, linux-arm64 or linux-amd64", errMissingAssets)
+	}
+
+	var buf bytes.Buffer
+	err := homebrewTemplate.Execute(&buf, struct {
+		Info  *packageInfo
+		Class string
+		OSes  []brewOS
+	}{p, formulaClass(p.Name), oses})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"Formula/" + p.Name + ".rb": buf.Bytes()}, nil
+}
+
+// formulaClass converts a formula name to the Ruby class Homebrew expects,
+// e.g. "baton-okta" becomes "BatonOkta".
+func formulaClass(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '-' || r == '_' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// rubyString quotes s as a double-quoted Ruby string literal without interpolation.
+func rubyString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `#`, `\#`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// --- Scoop ---
+
+type scoopManifest struct {
+	Version      string                       `json:"version"`
+	Description  string                       `json:"description"`
+	Homepage     string                       `json:"homepage"`
+	License      string                       `json:"license"`
+	Architecture map[string]scoopArchitecture `json:"architecture"`
+	Bin          string                       `json:"bin"`
+}
+
+type scoopArchitecture struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+// renderScoop renders bucket/{name}.json from the windows zip archives.
+func renderScoop(p *packageInfo, m *pb.Manifest) (map[string][]byte, error) {
+	arches := map[string]scoopArchitecture{}
+	for goarch, scoopArch := range map[string]string{"amd64": "64bit", "arm64": "arm64"} {
+		if asset, ok := m.GetAssets()["windows-"+goarch]; ok {
+			arches[scoopArch] = scoopArchitecture{URL: asset.GetHref(), Hash: strings.ToLower(asset.GetSha256())}
+		}
+	}
+	if len(arches) == 0 {
+		return nil, fmt.Errorf("%w: need windows-amd64 or windows-arm64", errMissingAssets)
+	}
+
+	// encoding/json sorts map keys and keeps struct field order, so the
+	// output is stable.
+	data, err := json.MarshalIndent(scoopManifest{
+		Version:      p.Version,
+		Description:  p.Description,
+		Homepage:     p.Homepage,
+		License:      p.License,
+		Architecture: arches,
+		Bin:          p.Binary + ".exe",
+	}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"bucket/" + p.Name + ".json": append(data, '\n')}, nil
+}
+
+// --- winget ---
+
+const wingetManifestVersion = "1.6.0"
+
+var wingetTemplates = template.Must(template.New("winget").Funcs(template.FuncMap{"yaml": yamlString}).Parse(`
+{{- define "header" }}# Generated by generate-package-manifests. DO NOT EDIT.
+# yaml-language-server: $schema=https://aka.ms/winget-manifest.{{ . }}.` + wingetManifestVersion + `.schema.json
+
+{{ end }}
+
+{{- define "version" }}{{ template "header" "version" -}}
+PackageIdentifier: {{ yaml .ID }}
+PackageVersion: {{ yaml .Info.Version }}
+DefaultLocale: en-US
+ManifestType: version
+ManifestVersion: ` + wingetManifestVersion + `
+{{ end }}
+
+{{- define "installer" }}{{ template "header" "installer" -}}
+PackageIdentifier: {{ yaml .ID }}
+PackageVersion: {{ yaml .Info.Version }}
+InstallerType: msi
+{{- if .Info.ReleaseDate }}
+ReleaseDate: {{ .Info.ReleaseDate }}
+{{- end }}
+Installers:
+- Architecture: x64
+  InstallerUrl: {{ yaml .Asset.GetHref }}
+  InstallerSha256: {{ .SHA256 }}
+ManifestType: installer
+ManifestVersion: ` + wingetManifestVersion + `
+{{ end }}
+
+{{- define "locale" }}{{ template "header" "defaultLocale" -}}
+PackageIdentifier: {{ yaml .ID }}
+PackageVersion: {{ yaml .Info.Version }}
+PackageLocale: en-US
+Publisher: {{ yaml .Info.Publisher }}
+PackageName: {{ yaml .Info.Name }}
+PackageUrl: {{ yaml .Info.Homepage }}
+License: {{ yaml .Info.License }}
+ShortDescription: {{ yaml .Info.Description }}
+ManifestType: defaultLocale
+ManifestVersion: ` + wingetManifestVersion + `
+{{ end }}`))
+
+// renderWinget renders the version, installer and default locale manifests
+// for the windows-amd64-msi asset under the winget-pkgs directory layout.
+func renderWinget(p *packageInfo, m *pb.Manifest) (map[string][]byte, error) {
+	asset, ok := m.GetAssets()["windows-amd64-msi"]
+	if !ok {
+		return nil, fmt.Errorf("%w: need windows-amd64-msi", errMissingAssets)
+	}
+
+	id := p.Publisher + "." + p.Name
+	dir := fmt.Sprintf("manifests/%s/%s/%s/%s/", strings.ToLower(p.Publisher[:1]), p.Publisher, p.Name, p.Version)
+	data := struct {
+		ID     string
+		Info   *packageInfo
+		Asset  *pb.Asset
+		SHA256 string
+	}{id, p, asset, strings.ToUpper(asset.GetSha256())}
+
+	files := map[string][]byte{}
+	for name, tmpl := range map[string]string{
+		id + ".yaml":              "version",
+		id + ".installer.yaml":    "installer",
+		id + ".locale.en-US.yaml": "locale",
+	} {
+		var buf bytes.Buffer
+		if err := wingetTemplates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+			return nil, err
+		}
+		files[dir+name] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// yamlString quotes s as a double-quoted YAML scalar.
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const baseURL = "https://dist.conductorone.com/releases/ConductorOne/baton-okta/v1.2.3/"
+
+func testManifest(keys ...string) *pb.Manifest {
+	assets := map[string]*pb.Asset{}
+	for _, key := range keys {
+		ext := ".tar.gz"
+		if strings.HasPrefix(key, "windows") {
+			ext = ".zip"
+		}
+		if strings.HasSuffix(key, "-msi") {
+			ext = ".msi"
+		}
+		filename := "baton-okta-v1.2.3-" + strings.TrimSuffix(key, "-msi") + ext
+		assets[key] = pb.Asset_builder{
+			Filename: strPtr(filename),
+			Href:     strPtr(baseURL + filename),
+			Sha256:   strPtr(strings.Repeat(key[:1], 64)),
+		}.Build()
+	}
+	return pb.Manifest_builder{
+		Org:        strPtr("ConductorOne"),
+		Name:       strPtr("baton-okta"),
+		Semver:     strPtr("v1.2.3"),
+		ReleasedAt: timestamppb.New(time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)),
+		Assets:     assets,
+	}.Build()
+}
+
+func render(t *testing.T, r renderer, m *pb.Manifest) map[string][]byte {
+	t.Helper()
+	p, err := newPackageInfo(m, "", "", "Apache-2.0", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := r(p, m)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	// Rendering the same manifest twice must give identical bytes.
+	again, err := r(p, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range files {
+		if !bytes.Equal(data, again[path]) {
+			t.Fatalf("%s is not deterministic", path)
+		}
+	}
+	return files
+}
+
+func TestRenderHomebrew(t *testing.T) {
+	m := testManifest("darwin-arm64", "darwin-amd64", "linux-amd64", "windows-amd64")
+	files := render(t, renderHomebrew, m)
+
+	formula := string(files["Formula/baton-okta.rb"])
+	for _, want := range []string{
+		"class BatonOkta < Formula",
+		`desc "baton-okta by ConductorOne"`,
+		`version "1.2.3"`,
+		"  on_macos do\n    on_arm do\n      url \"" + baseURL + "baton-okta-v1.2.3-darwin-arm64.tar.gz\"\n      sha256 \"" + strings.Repeat("d", 64) + "\"\n    end\n    on_intel do\n",
+		"  on_linux do\n    on_intel do\n      url \"" + baseURL + "baton-okta-v1.2.3-linux-amd64.tar.gz\"",
+		`bin.install "baton-okta"`,
+	} {
+		if !strings.Contains(formula, want) {
+			t.Errorf("formula missing %q:\n%s", want, formula)
+		}
+	}
+	if strings.Contains(formula, "windows") {
+		t.Errorf("formula references a windows asset:\n%s", formula)
+	}
+}
+
+func TestRenderScoop(t *testing.T) {
+	files := render(t, renderScoop, testManifest("linux-amd64", "windows-amd64", "windows-amd64-msi"))
+
+	var got scoopManifest
+	if err := json.Unmarshal(files["bucket/baton-okta.json"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "1.2.3" || got.Bin != "baton-okta.exe" {
+		t.Errorf("version, bin = %q, %q", got.Version, got.Bin)
+	}
+	if len(got.Architecture) != 1 || got.Architecture["64bit"].URL != baseURL+"baton-okta-v1.2.3-windows-amd64.zip" {
+		t.Errorf("architecture = %+v", got.Architecture)
+	}
+}
+
+func TestRenderWinget(t *testing.T) {
+	files := render(t, renderWinget, testManifest("windows-amd64", "windows-amd64-msi"))
+
+	dir := "manifests/c/ConductorOne/baton-okta/1.2.3/"
+	installer := string(files[dir+"ConductorOne.baton-okta.installer.yaml"])
+	for _, want := range []string{
+		`PackageIdentifier: "ConductorOne.baton-okta"`,
+		"InstallerType: msi\nReleaseDate: 2025-03-04\n",
+		`InstallerUrl: "` + baseURL + `baton-okta-v1.2.3-windows-amd64.msi"`,
+		"InstallerSha256: " + strings.Repeat("W", 64),
+	} {
+		if !strings.Contains(installer, want) {
+			t.Errorf("installer manifest missing %q:\n%s", want, installer)
+		}
+	}
+	if !strings.Contains(string(files[dir+"ConductorOne.baton-okta.yaml"]), "ManifestType: version\n") {
+		t.Errorf("version manifest:\n%s", files[dir+"ConductorOne.baton-okta.yaml"])
+	}
+	if !strings.Contains(string(files[dir+"ConductorOne.baton-okta.locale.en-US.yaml"]), `License: "Apache-2.0"`) {
+		t.Errorf("locale manifest:\n%s", files[dir+"ConductorOne.baton-okta.locale.en-US.yaml"])
+	}
+}
+
+func TestRenderSkipsMissingAssets(t *testing.T) {
+	m := testManifest("linux-amd64")
+	p, err := newPackageInfo(m, "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, r := range map[string]renderer{"scoop": renderScoop, "winget": renderWinget} {
+		if _, err := r(p, m); !errors.Is(err, errMissingAssets) {
+			t.Errorf("%s: err = %v, want errMissingAssets", name, err)
+		}
+	}
+}
+
+func TestFormulaClassAndRubyString(t *testing.T) {
+	if got := formulaClass("baton-1password_cli"); got != "Baton1passwordCli" {
+		t.Errorf("formulaClass = %q", got)
+	}
+	if got := rubyString(`a "#{x}" \`); got != `"a \"\#{x}\" \\"` {
+		t.Errorf("rubyString = %s", got)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -1,39 +1,29 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"google.golang.org/protobuf/encoding/protojson"
-
+	"github.com/ConductorOne/github-workflows/internal/manifest"
 	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
 )
 
-const (
-	// AttestationTypeInTotoV1 is the in-toto Statement v1 envelope type
-	AttestationTypeInTotoV1 = "https://in-toto.io/Statement/v1"
-	// PredicateTypeSLSAProvenanceV1 is the SLSA v1 provenance predicate type
-	PredicateTypeSLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
-	// PredicateTypeSPDX is the SPDX SBOM predicate type
-	PredicateTypeSPDX = "https://spdx.dev/Document"
-)
-
 func main() {
 	var (
 		distDir    string
 		cdnBaseURL string
 		s3Dir      string
+		commitSha  string
 	)
 	flag.StringVar(&distDir, "dist-dir", "", "Path to the dist directory containing Windows artifacts")
 	flag.StringVar(&cdnBaseURL, "cdn-base-url", "", "CDN base URL for artifact links")
 	flag.StringVar(&s3Dir, "s3-directory", "", "S3 directory path for artifacts")
+	flag.StringVar(&commitSha, "commit-sha", "", "Release commit; when set, the zipped binary's vcs.revision must equal it and vcs.modified must be false (optional)")
 	flag.Parse()
 
 	if distDir == "" || cdnBaseURL == "" || s3Dir == "" {
@@ -65,12 +55,17 @@ func main() {
 			continue
 		}
 
-		asset, err := buildAsset(zipPath, filename, "application/zip", baseURL, distDir)
+		asset, err := manifest.NewAsset(distDir, filename, manifest.AssetOptions{MediaType: "application/zip", BaseURL: baseURL})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "generate-windows-manifest: error processing %s: %v\n", filename, err)
 			os.Exit(1)
 		}
 
+		if err := recordBuildInfo(asset, zipPath, commitSha); err != nil {
+			fmt.Fprintf(os.Stderr, "generate-windows-manifest: error: %v\n", err)
+			os.Exit(1)
+		}
+
 		// Windows zip uses key "windows-amd64"
 		assets["windows-amd64"] = asset
 		fmt.Fprintf(os.Stderr, "✅ Added zip asset: windows-amd64 -> %s\n", filename)
@@ -86,7 +81,7 @@ func main() {
 	for _, msiPath := range msiFiles {
 		filename := filepath.Base(msiPath)
 
-		asset, err := buildAsset(msiPath, filename, "application/x-msi", baseURL, distDir)
+		asset, err := manifest.NewAsset(distDir, filename, manifest.AssetOptions{MediaType: "application/x-msi", BaseURL: baseURL})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "generate-windows-manifest: error processing %s: %v\n", filename, err)
 			os.Exit(1)
@@ -99,14 +94,11 @@ func main() {
 	}
 
 	// Marshal assets map to JSON
-	// We need to output a map[string]Asset JSON, not a full manifest
+	// We need to output a map[string]Asset JSON, not a full manifest.
+	// json.Marshal compacts each RawMessage, so the output stays on one line.
 	output := make(map[string]json.RawMessage)
-	marshalOpts := protojson.MarshalOptions{
-		EmitUnpopulated: true,
-	}
-
 	for key, asset := range assets {
-		jsonBytes, err := marshalOpts.Marshal(asset)
+		jsonBytes, err := manifest.Marshal(asset)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "generate-windows-manifest: error marshaling asset %s: %v\n", key, err)
 			os.Exit(1)
@@ -125,87 +117,24 @@ func main() {
 	fmt.Fprintf(os.Stderr, "✅ Generated Windows manifest with %d assets\n", len(assets))
 }
 
-func buildAsset(filePath, filename, mediaType, baseURL, distDir string) (*pb.Asset, error) {
-	// Calculate SHA256
-	hash, err := sha256File(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("calculating hash: %w", err)
-	}
-
-	// Get file size
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("getting file info: %w", err)
-	}
-
-	sizeBytes := info.Size()
-	href := fmt.Sprintf("%s/%s", baseURL, filename)
-
-	// Check for signature and certificate files (all in dist root after flatten step)
-	var signatureHref, certificateHref *string
-	sigPath := filepath.Join(distDir, filename+".sig")
-	if _, err := os.Stat(sigPath); err == nil {
-		s := fmt.Sprintf("%s/%s.sig", baseURL, filename)
-		signatureHref = &s
-	}
-	certPath := filepath.Join(distDir, filename+".cert")
-	if _, err := os.Stat(certPath); err == nil {
-		c := fmt.Sprintf("%s/%s.cert", baseURL, filename)
-		certificateHref = &c
-	}
-
-	// Build attestations array
-	var attestations []*pb.AttestationDescriptor
-
-	// Check for provenance attestation
-	provenancePath := filepath.Join(distDir, filename+".provenance.sigstore.json")
-	if _, err := os.Stat(provenancePath); err == nil {
-		attestationType := AttestationTypeInTotoV1
-		predicateType := PredicateTypeSLSAProvenanceV1
-		bundleHref := fmt.Sprintf("%s/%s.provenance.sigstore.json", baseURL, filename)
-		attestations = append(attestations, pb.AttestationDescriptor_builder{
-			AttestationType: &attestationType,
-			PredicateType:   &predicateType,
-			BundleHref:      &bundleHref,
-		}.Build())
-	}
-
-	// Check for SBOM attestation
-	sbomPath := filepath.Join(distDir, filename+".sbom.sigstore.json")
-	if _, err := os.Stat(sbomPath); err == nil {
-		attestationType := AttestationTypeInTotoV1
-		predicateType := PredicateTypeSPDX
-		bundleHref := fmt.Sprintf("%s/%s.sbom.sigstore.json", baseURL, filename)
-		attestations = append(attestations, pb.AttestationDescriptor_builder{
-			AttestationType: &attestationType,
-			PredicateType:   &predicateType,
-			BundleHref:      &bundleHref,
-		}.Build())
-	}
-
-	return pb.Asset_builder{
-		Filename:        &filename,
-		MediaType:       &mediaType,
-		SizeBytes:       &sizeBytes,
-		Sha256:          &hash,
-		Href:            &href,
-		SignatureHref:   signatureHref,
-		CertificateHref: certificateHref,
-		Attestations:    attestations,
-	}.Build(), nil
-}
-
-func sha256File(path string) (string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-
-	return hex.EncodeToString(h.Sum(nil)), nil
+// recordBuildInfo sets the build info of the Go binary in the zip at path on
+// asset. With commitSha set the binary must have been built from that commit,
+// and a zip without a Go binary is an error rather than a warning.
+func recordBuildInfo(asset *pb.Asset, path, commitSha string) error {
+	info, binary, err := manifest.ReadArchiveBuildInfo(path)
+	switch {
+	case errors.Is(err, manifest.ErrNoGoBinary) && commitSha != "":
+		return fmt.Errorf("%s contains no Go binary, so it cannot be verified against commit %s", asset.GetFilename(), commitSha)
+	case errors.Is(err, manifest.ErrNoGoBinary):
+		fmt.Fprintf(os.Stderr, "generate-windows-manifest: warning: %s contains no Go binary; build info not recorded\n", asset.GetFilename())
+		return nil
+	case err != nil:
+		return fmt.Errorf("reading build info: %w", err)
+	}
+	manifest.SetBuildInfo(asset, info)
+	fmt.Fprintf(os.Stderr, "✅ Read build info from %s in %s (%s)\n", binary, asset.GetFilename(), info.GoVersion)
+	if commitSha == "" {
+		return nil
+	}
+	return manifest.VerifyBuildRevision(asset, commitSha)
 }
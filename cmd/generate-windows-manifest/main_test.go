@@ -0,0 +1,85 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// writeZip creates a zip in dir holding a README and, with binary set, the
+// running test binary, which carries Go build info but no VCS stamp.
+func writeZip(t *testing.T, dir, name string, binary bool) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("# baton-example\n"))
+	if binary {
+		exe, err := os.Executable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create("baton-example.exe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecordBuildInfo(t *testing.T) {
+	path := writeZip(t, t.TempDir(), "baton-example-v0.1.2-windows-amd64.zip", true)
+	asset := pb.Asset_builder{Filename: strPtr(filepath.Base(path))}.Build()
+	if err := recordBuildInfo(asset, path, ""); err != nil {
+		t.Fatalf("recordBuildInfo: %v", err)
+	}
+	if asset.GetGoVersion() != runtime.Version() {
+		t.Fatalf("go_version = %q, want %q", asset.GetGoVersion(), runtime.Version())
+	}
+}
+
+func TestRecordBuildInfoWithCommitSha(t *testing.T) {
+	dir := t.TempDir()
+	path := writeZip(t, dir, "baton-example-v0.1.2-windows-amd64.zip", true)
+	asset := pb.Asset_builder{Filename: strPtr(filepath.Base(path))}.Build()
+	// Test binaries are not VCS stamped, so they cannot match any commit.
+	err := recordBuildInfo(asset, path, "0123456789abcdef0123456789abcdef01234567")
+	if err == nil || !strings.Contains(err.Error(), "without VCS stamping") {
+		t.Fatalf("err = %v, want a missing vcs.revision error", err)
+	}
+
+	path = writeZip(t, dir, "docs-windows-amd64.zip", false)
+	asset = pb.Asset_builder{Filename: strPtr(filepath.Base(path))}.Build()
+	if err := recordBuildInfo(asset, path, ""); err != nil {
+		t.Fatalf("without -commit-sha: %v, want only a warning", err)
+	}
+	err = recordBuildInfo(asset, path, "0123456789abcdef0123456789abcdef01234567")
+	if err == nil || !strings.Contains(err.Error(), "contains no Go binary") {
+		t.Fatalf("err = %v, want a single no Go binary error", err)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/ConductorOne/github-workflows/internal/attestation"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/pkg/distclient"
+)
+
+func main() {
+	var (
+		source     string
+		baseURL    string
+		org        string
+		repo       string
+		version    string
+		goos       string
+		goarch     string
+		binary     string
+		installDir string
+		cacheDir   string
+		outputPath string
+		timeout    time.Duration
+
+		trustedRootPath string
+		identityRegexp  string
+		oidcIssuer      string
+	)
+	flag.StringVar(&source, "source", sourceGitHub, `Where releases are read from: "github" (GitHub releases, verified against the release's checksums file) or "dist" (the release manifests under -base-url)`)
+	flag.StringVar(&baseURL, "base-url", distclient.DefaultBaseURL, `Base URL of the releases tree for -source dist`)
+	flag.StringVar(&org, "org", "ConductorOne", "GitHub organization")
+	flag.StringVar(&repo, "repo", "baton", "Repository to download")
+	flag.StringVar(&version, "version", distclient.Stable, `Release version tag (e.g. v0.2.4) or "stable"`)
+	flag.StringVar(&goos, "os", runtime.GOOS, "Target operating system")
+	flag.StringVar(&goarch, "arch", runtime.GOARCH, "Target architecture")
+	flag.StringVar(&binary, "binary", "", "Name of the binary inside the archive (optional, defaults to the repository name)")
+	flag.StringVar(&installDir, "install-dir", "/usr/local/bin", "Directory to install the binary into")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory for downloaded archives, keyed by sha256 (optional, defaults to the user cache directory)")
+	flag.StringVar(&outputPath, "github-output", "", "File to append version=, sha256= and path= lines to, e.g. $GITHUB_OUTPUT (optional)")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "Overall timeout for all downloads")
+	flag.StringVar(&trustedRootPath, "trusted-root", "", "Sigstore trusted_root.json; when set, the asset's attestation bundles must verify before the binary is installed")
+	flag.StringVar(&identityRegexp, "certificate-identity-regexp", attestation.DefaultCertificateIdentityRegexp, "Regexp the attestation signing certificate identity must match")
+	flag.StringVar(&oidcIssuer, "certificate-oidc-issuer", attestation.DefaultCertificateOIDCIssuer, "OIDC issuer the attestation signing certificate must carry")
+	flag.Parse()
+
+	if binary == "" {
+		binary = repo
+	}
+	if goos == "windows" {
+		binary += ".exe"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := &distclient.Client{BaseURL: baseURL, CacheDir: cacheDir}
+	inst := &installer{
+		client:   client,
+		releases: client,
+		logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+	switch source {
+	case sourceDist:
+	case sourceGitHub:
+		inst.releases = &distclient.GitHubReleases{Token: os.Getenv("GITHUB_TOKEN")}
+	default:
+		fmt.Fprintf(os.Stderr, "get-baton: error: source must be %s or %s, got %q\n", sourceGitHub, sourceDist, source)
+		os.Exit(1)
+	}
+	if trustedRootPath != "" {
+		if source != sourceDist {
+			fmt.Fprintf(os.Stderr, "get-baton: error: -trusted-root requires -source %s; GitHub releases carry no attestation bundles\n", sourceDist)
+			os.Exit(1)
+		}
+		trustedRoot, err := attestation.LoadTrustedRoot(trustedRootPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "get-baton: error: %v\n", err)
+			os.Exit(1)
+		}
+		inst.attestations, err = attestation.NewVerifier(trustedRoot, identityRegexp, oidcIssuer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "get-baton: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result, err := inst.install(ctx, org, repo, version, goos, goarch, binary, installDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "get-baton: error: %v\n", err)
+		os.Exit(1)
+	}
+	if outputPath != "" {
+		if err := writeOutputs(outputPath, result); err != nil {
+			fmt.Fprintf(os.Stderr, "get-baton: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println(result.Path)
+}
+
+// installed describes the binary an install placed.
+type installed struct {
+	// Path is where the binary was installed.
+	Path string
+	// Version is the release version "stable" resolved to, or the requested tag.
+	Version string
+	// SHA256 is the digest of the downloaded archive.
+	SHA256 string
+}
+
+// writeOutputs appends result to a GitHub Actions output file, so a workflow
+// can key its cache on what "stable" resolved to.
+func writeOutputs(path string, result *installed) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening output file: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "version=%s\nsha256=%s\npath=%s\n", result.Version, result.SHA256, result.Path); err != nil {
+		f.Close()
+		return fmt.Errorf("writing output file: %w", err)
+	}
+	return f.Close()
+}
+
+// Release sources selectable with -source.
+const (
+	sourceGitHub = "github"
+	sourceDist   = "dist"
+)
+
+// releaseSource resolves a version to a manifest describing its assets.
+// distclient.Client reads published manifests; distclient.GitHubReleases
+// builds them from GitHub releases.
+type releaseSource interface {
+	Manifest(ctx context.Context, org, repo, version string) (*pb.Manifest, error)
+}
+
+// installer downloads a verified release archive and installs one binary from it.
+type installer struct {
+	client   *distclient.Client
+	releases releaseSource
+	// attestations verifies the asset's bundles; when nil attestations are not checked.
+	attestations *attestation.Verifier
+	logf         func(format string, args ...any)
+}
+
+// install resolves version, downloads and verifies the archive for goos/goarch,
+// and copies binary from it into installDir.
+func (i *installer) install(ctx context.Context, org, repo, version, goos, goarch, binary, installDir string) (*installed, error) {
+	m, err := i.releases.Manifest(ctx, org, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	key, asset, err := distclient.SelectAsset(m, goos, goarch)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := i.client.Download(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	i.logf("✅ Downloaded %s (sha256 %s)", asset.GetFilename(), asset.GetSha256())
+
+	if i.attestations != nil {
+		if err := i.verifyAttestations(ctx, asset); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "get-baton-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := distclient.Unpack(archive, tmp); err != nil {
+		return nil, err
+	}
+	src, err := findBinary(tmp, binary)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", asset.GetFilename(), err)
+	}
+
+	dst := filepath.Join(installDir, binary)
+	if err := installFile(src, dst); err != nil {
+		return nil, err
+	}
+	i.logf("✅ Installed %s %s (%s) to %s", repo, m.GetSemver(), key, dst)
+	return &installed{Path: dst, Version: m.GetSemver(), SHA256: asset.GetSha256()}, nil
+}
+
+// verifyAttestations requires at least one attestation on asset and verifies
+// every bundle it references.
+func (i *installer) verifyAttestations(ctx context.Context, asset *pb.Asset) error {
+	if len(asset.GetAttestations()) == 0 {
+		return fmt.Errorf("%s has no attestations to verify", asset.GetFilename())
+	}
+	for _, att := range asset.GetAttestations() {
+		data, err := i.client.Bundle(ctx, att)
+		if err != nil {
+			return err
+		}
+		if err := i.attestations.VerifyBundleJSON(data, asset, att); err != nil {
+			return fmt.Errorf("attestation %s: %w", att.GetPredicateType(), err)
+		}
+		i.logf("✅ Verified %s attestation", att.GetPredicateType())
+	}
+	return nil
+}
+
+// findBinary returns the path of the regular file named name under dir.
+func findBinary(dir, name string) (string, error) {
+	var found string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && d.Name() == name {
+			found = path
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("archive does not contain %s", name)
+	}
+	return found, nil
+}
+
+// installFile copies src to dst with executable permissions, replacing dst
+// atomically so a running binary is never truncated.
+func installFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(dst), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return fmt.Errorf("installing %s: %w", dst, err)
+	}
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("installing %s: %w", dst, err)
+	}
+	err = errors.Join(tmp.Chmod(0o755), tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("installing %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+
+	"github.com/ConductorOne/github-workflows/internal/attestation"
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/pkg/distclient"
+)
+
+const batonContent = "#!/bin/sh\necho baton\n"
+
+// newReleaseServer serves ConductorOne/baton v0.2.0 with a linux-amd64
+// archive that holds the binary at baton-v0.2.0/baton.
+func newReleaseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "baton-v0.2.0/", Mode: 0o755, Typeflag: tar.TypeDir}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "baton-v0.2.0/baton", Mode: 0o755, Size: int64(len(batonContent)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write([]byte(batonContent))
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+	sum := sha256.Sum256(archive)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	size := int64(len(archive))
+	m := pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("ConductorOne"),
+		Name:    strPtr("baton"),
+		Semver:  strPtr("v0.2.0"),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{
+				Filename:  strPtr("baton-v0.2.0-linux-amd64.tar.gz"),
+				SizeBytes: &size,
+				Sha256:    strPtr(hex.EncodeToString(sum[:])),
+				Href:      strPtr(srv.URL + "/ConductorOne/baton/v0.2.0/baton-v0.2.0-linux-amd64.tar.gz"),
+			}.Build(),
+		},
+	}.Build()
+	manifestJSON, err := manifest.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.HandleFunc("/ConductorOne/baton/v0.2.0/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(manifestJSON)
+	})
+	mux.HandleFunc("/ConductorOne/baton/v0.2.0/baton-v0.2.0-linux-amd64.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	return srv
+}
+
+func TestInstallCopiesBinaryFromVerifiedArchive(t *testing.T) {
+	srv := newReleaseServer(t)
+	client := &distclient.Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: t.TempDir()}
+	inst := &installer{client: client, releases: client, logf: t.Logf}
+
+	installDir := t.TempDir()
+	result, err := inst.install(context.Background(), "ConductorOne", "baton", "v0.2.0", "linux", "amd64", "baton", installDir)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	path := result.Path
+	if path != filepath.Join(installDir, "baton") || result.Version != "v0.2.0" || result.SHA256 == "" {
+		t.Fatalf("installed = %+v", result)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != batonContent {
+		t.Fatalf("installed content = %q", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Fatalf("installed mode = %v, want 0755", info.Mode().Perm())
+	}
+}
+
+func TestInstallReportsMissingPlatform(t *testing.T) {
+	srv := newReleaseServer(t)
+	client := &distclient.Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: t.TempDir()}
+	inst := &installer{client: client, releases: client, logf: t.Logf}
+	_, err := inst.install(context.Background(), "ConductorOne", "baton", "v0.2.0", "darwin", "arm64", "baton", t.TempDir())
+	if !errors.Is(err, distclient.ErrNoAsset) {
+		t.Fatalf("err = %v, want ErrNoAsset", err)
+	}
+}
+
+func TestInstallRequiresAttestationsWhenVerifying(t *testing.T) {
+	srv := newReleaseServer(t)
+	vs, err := ca.NewVirtualSigstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := attestation.NewVerifier(vs, ".*", attestation.DefaultCertificateOIDCIssuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &distclient.Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: t.TempDir()}
+	inst := &installer{
+		client:       client,
+		releases:     client,
+		attestations: verifier,
+		logf:         t.Logf,
+	}
+
+	installDir := t.TempDir()
+	_, err = inst.install(context.Background(), "ConductorOne", "baton", "v0.2.0", "linux", "amd64", "baton", installDir)
+	if err == nil || !strings.Contains(err.Error(), "no attestations") {
+		t.Fatalf("err = %v, want missing attestations", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "baton")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("binary was installed without verified attestations")
+	}
+}
+
+func TestFindBinaryMissing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findBinary(dir, "baton"); err == nil {
+		t.Fatal("expected an error for an archive without the binary")
+	}
+}
+
+func TestWriteOutputsAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	if err := os.WriteFile(path, []byte("earlier=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutputs(path, &installed{Path: "/usr/local/bin/baton", Version: "v0.2.0", SHA256: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "earlier=1\nversion=v0.2.0\nsha256=abc\npath=/usr/local/bin/baton\n"
+	if string(data) != want {
+		t.Fatalf("output file:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
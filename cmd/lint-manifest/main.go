@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+)
+
+// Result is the machine-readable lint outcome for one document.
+type Result struct {
+	File   string               `json:"file"`
+	Valid  bool                 `json:"valid"`
+	Errors []manifest.LintError `json:"errors"`
+}
+
+func main() {
+	var (
+		manifestPath string
+		stablePath   string
+		publishPath  string
+		format       string
+	)
+	flag.StringVar(&manifestPath, "manifest", "", "Path to a manifest.json to lint")
+	flag.StringVar(&stablePath, "stable", "", "Path to a stable.json to lint")
+	flag.StringVar(&publishPath, "path", "", "Published path of the document, e.g. releases/{org}/{repo}/{tag}/manifest.json; when set, org, name and tag must match it")
+	flag.StringVar(&format, "format", "text", "Output format: text or json")
+	flag.Parse()
+
+	if (manifestPath == "") == (stablePath == "") {
+		fmt.Fprintf(os.Stderr, "lint-manifest: error: exactly one of manifest or stable is required\n")
+		os.Exit(1)
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "lint-manifest: error: format must be text or json, got %q\n", format)
+		os.Exit(1)
+	}
+
+	result, err := lint(manifestPath, stablePath, publishPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint-manifest: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if format == "json" {
+		err = writeJSON(os.Stdout, result)
+	} else {
+		err = writeText(os.Stdout, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint-manifest: error: writing result: %v\n", err)
+		os.Exit(1)
+	}
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
+
+// lint reads the manifest or stable document and checks it, and its published
+// path when publishPath is set.
+func lint(manifestPath, stablePath, publishPath string) (*Result, error) {
+	var errs []manifest.LintError
+	file := manifestPath
+	if manifestPath != "" {
+		m, err := manifest.ReadManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		errs = manifest.LintManifest(m)
+		if publishPath != "" {
+			errs = append(errs, manifest.LintManifestPath(m, publishPath)...)
+		}
+	} else {
+		file = stablePath
+		stable, err := manifest.ReadStable(stablePath)
+		if err != nil {
+			return nil, err
+		}
+		errs = manifest.LintStable(stable)
+		if publishPath != "" {
+			errs = append(errs, manifest.LintStablePath(stable, publishPath)...)
+		}
+	}
+	if errs == nil {
+		errs = []manifest.LintError{}
+	}
+	return &Result{File: file, Valid: len(errs) == 0, Errors: errs}, nil
+}
+
+func writeJSON(w io.Writer, result *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func writeText(w io.Writer, result *Result) error {
+	var sb strings.Builder
+	if result.Valid {
+		fmt.Fprintf(&sb, "✅ %s is valid\n", result.File)
+	} else {
+		fmt.Fprintf(&sb, "❌ %s has %d error(s):\n", result.File, len(result.Errors))
+		for _, e := range result.Errors {
+			fmt.Fprintf(&sb, "  %s\n", e)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func testManifest() *pb.Manifest {
+	size := int64(1000)
+	return pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("ConductorOne"),
+		Name:    strPtr("baton-example"),
+		Semver:  strPtr("v0.1.2"),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{
+				Filename:     strPtr("baton-example-v0.1.2-linux-amd64.tar.gz"),
+				SizeBytes:    &size,
+				Sha256:       strPtr(strings.Repeat("a", 64)),
+				Href:         strPtr("https://dist.example.com/baton-example-v0.1.2-linux-amd64.tar.gz"),
+				Attestations: []*pb.AttestationDescriptor{manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, "https://dist.example.com/a.provenance.sigstore.json")},
+			}.Build(),
+		},
+	}.Build()
+}
+
+// writeDocument marshals m to dir/name and returns its path.
+func writeDocument(t *testing.T, dir, name string, m proto.Message) string {
+	t.Helper()
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLintValidManifest(t *testing.T) {
+	path := writeDocument(t, t.TempDir(), "releases/ConductorOne/baton-example/v0.1.2/manifest.json", testManifest())
+	result, err := lint(path, "", "releases/ConductorOne/baton-example/v0.1.2/manifest.json")
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if !result.Valid || len(result.Errors) != 0 {
+		t.Fatalf("result = %+v, want valid", result)
+	}
+
+	var out bytes.Buffer
+	if err := writeText(&out, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "is valid") {
+		t.Fatalf("text output = %q", out.String())
+	}
+}
+
+func TestLintStablePathMismatch(t *testing.T) {
+	stable := pb.Stable_builder{
+		Version:   strPtr("v0.1.2"),
+		UpdatedAt: timestamppb.New(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Manifest:  testManifest(),
+	}.Build()
+	path := writeDocument(t, t.TempDir(), "stable.json", stable)
+
+	result, err := lint("", path, "releases/ConductorOne/baton-other/stable.json")
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "manifest.name" {
+		t.Fatalf("result = %+v, want a single manifest.name error", result)
+	}
+
+	var out bytes.Buffer
+	if err := writeText(&out, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "has 1 error(s)") || !strings.Contains(out.String(), `manifest.name: "baton-example" does not match path repo "baton-other"`) {
+		t.Fatalf("text output:\n%s", out.String())
+	}
+}
+
+func TestLintJSONOutput(t *testing.T) {
+	m := testManifest()
+	m.SetSemver("0.1.2")
+	path := writeDocument(t, t.TempDir(), "manifest.json", m)
+	result, err := lint(path, "", "")
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		File   string `json:"file"`
+		Valid  bool   `json:"valid"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding %s: %v", out.String(), err)
+	}
+	if decoded.File != path || decoded.Valid || len(decoded.Errors) != 1 || decoded.Errors[0].Field != "semver" {
+		t.Fatalf("json output = %s", out.String())
+	}
+
+	// A valid document still reports an empty errors array, not null.
+	result, err = lint(writeDocument(t, t.TempDir(), "manifest.json", testManifest()), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := writeJSON(&out, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"errors": []`) {
+		t.Fatalf("json output = %s", out.String())
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
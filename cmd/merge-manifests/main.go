@@ -1,175 +1,428 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
+	"github.com/ConductorOne/github-workflows/internal/manifest"
 	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
 )
 
+// fragmentKind names the JSON shape of a merge input.
+type fragmentKind string
+
 const (
-	// AttestationTypeInTotoV1 is the in-toto Statement v1 envelope type
-	AttestationTypeInTotoV1 = "https://in-toto.io/Statement/v1"
-	// PredicateTypeSLSAProvenanceV1 is the SLSA v1 provenance predicate type
-	PredicateTypeSLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
+	// kindManifest is a full or partial Manifest; its set fields and map entries are merged.
+	kindManifest fragmentKind = "manifest"
+	// kindAssets is a {"key": Asset} map, as written by generate-windows-manifest.
+	kindAssets fragmentKind = "assets"
+	// kindImages is a {"key": Image} map, as written by extract-images.
+	kindImages fragmentKind = "images"
 )
 
+// conflictPolicy decides what happens when two fragments provide the same
+// assets or images key, or different values for the same manifest field.
+type conflictPolicy string
+
+const (
+	// policyError fails on any repeated key, and on differing field values.
+	policyError conflictPolicy = "error"
+	// policyPreferFirst keeps the value from the earliest fragment.
+	policyPreferFirst conflictPolicy = "prefer-first"
+	// policyPreferLast keeps the value from the latest fragment.
+	policyPreferLast conflictPolicy = "prefer-last"
+	// policyRequireIdentical allows repeats only when the values are identical.
+	policyRequireIdentical conflictPolicy = "require-identical"
+)
+
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	switch p := conflictPolicy(s); p {
+	case policyError, policyPreferFirst, policyPreferLast, policyRequireIdentical:
+		return p, nil
+	default:
+		return "", fmt.Errorf("on-conflict must be error, prefer-first, prefer-last or require-identical, got %q", s)
+	}
+}
+
+// fragment is one merge input.
+type fragment struct {
+	kind fragmentKind
+	// source names the input in messages: a file path, "stdin" or a flag name.
+	source string
+	data   []byte
+}
+
+// fragmentFlags collects repeated -fragment kind=path flags.
+type fragmentFlags []string
+
+func (f *fragmentFlags) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *fragmentFlags) Set(value string) error {
+	if _, _, err := parseFragmentSpec(value); err != nil {
+		return err
+	}
+	*f = append(*f, value)
+	return nil
+}
+
 func main() {
 	var (
 		binariesManifest string
 		imagesManifest   string
 		windowsManifest  string
+		fragmentSpecs    fragmentFlags
+		onConflict       string
+		uniformAtt       bool
 	)
-	flag.StringVar(&binariesManifest, "binaries-manifest", "", "JSON string of binaries manifest")
-	flag.StringVar(&imagesManifest, "images-manifest", "", "JSON string of images manifest (optional)")
-	flag.StringVar(&windowsManifest, "windows-manifest", "", "JSON string of Windows assets manifest (optional)")
+	flag.StringVar(&binariesManifest, "binaries-manifest", "", "JSON string of binaries manifest (same as a manifest fragment)")
+	flag.StringVar(&imagesManifest, "images-manifest", "", "JSON string of images manifest (optional, same as an images fragment)")
+	flag.StringVar(&windowsManifest, "windows-manifest", "", "JSON string of Windows assets manifest (optional, same as an assets fragment)")
+	flag.Var(&fragmentSpecs, "fragment", "Merge input as kind=path, where kind is manifest, assets or images and path is a file or - for stdin (repeatable, merged in order after the JSON string flags)")
+	flag.StringVar(&onConflict, "on-conflict", string(policyError), "What to do when fragments repeat a key or disagree on a field: error, prefer-first, prefer-last or require-identical")
+	flag.BoolVar(&uniformAtt, "require-uniform-attestations", false, "Fail unless every attested asset carries the same attestation predicate types (default: warn)")
 	flag.Parse()
 
-	if binariesManifest == "" {
-		fmt.Fprintf(os.Stderr, "merge-manifests: error: binaries-manifest is required\n")
+	policy, err := parseConflictPolicy(onConflict)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "merge-manifests: error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Parse binaries manifest
-	manifest := &pb.Manifest{}
-	opts := protojson.UnmarshalOptions{
-		DiscardUnknown: true,
+	var fragments []fragment
+	for _, legacy := range []fragment{
+		{kindManifest, "binaries_manifest", []byte(binariesManifest)},
+		{kindImages, "images_manifest", []byte(imagesManifest)},
+		{kindAssets, "windows_manifest", []byte(windowsManifest)},
+	} {
+		if len(legacy.data) > 0 {
+			fragments = append(fragments, legacy)
+		}
+	}
+	stdinUsed := false
+	for _, spec := range fragmentSpecs {
+		f, err := loadFragment(spec, os.Stdin, &stdinUsed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "merge-manifests: error: %v\n", err)
+			os.Exit(1)
+		}
+		fragments = append(fragments, f)
 	}
-	if err := opts.Unmarshal([]byte(binariesManifest), manifest); err != nil {
-		fmt.Fprintf(os.Stderr, "merge-manifests: ::error::Invalid JSON in binaries_manifest output\n")
-		fmt.Fprintf(os.Stderr, "merge-manifests: Raw content:\n%s\n", binariesManifest)
-		fmt.Fprintf(os.Stderr, "merge-manifests: Error: %v\n", err)
+	if len(fragments) == 0 {
+		fmt.Fprintf(os.Stderr, "merge-manifests: error: at least one fragment is required (-fragment or -binaries-manifest)\n")
 		os.Exit(1)
 	}
 
-	if manifest.GetVersion() == "" {
-		fmt.Fprintf(os.Stderr, "merge-manifests: ::error::Binaries manifest is empty\n")
+	mg := &merger{
+		policy:                     policy,
+		requireUniformAttestations: uniformAtt,
+		logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+	m, err := mg.merge(fragments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "merge-manifests: ::error::%v\n", err)
 		os.Exit(1)
 	}
 
-	// Marshal options with frontend consumption in mind. Ensures all fields are present for predictable structure.
-	marshalOpts := protojson.MarshalOptions{
-		Multiline:       true,
-		Indent:          "  ",
-		EmitUnpopulated: true,
+	// Refuse to emit a manifest that lint-manifest would reject
+	if errs := manifest.LintManifest(m); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "merge-manifests: ::error::%s\n", e)
+		}
+		fmt.Fprintf(os.Stderr, "merge-manifests: error: merged manifest has %d lint error(s)\n", len(errs))
+		os.Exit(1)
 	}
 
-	// Merge images if present
-	if imagesManifest != "" && imagesManifest != "{}" {
-		// Unmarshal images manifest as a map[string]*pb.Image using protojson
-		// The JSON format is: { "key": { "ref": "...", "digest": "..." }, ... }
-		var imagesMapJSON map[string]json.RawMessage
-		if err := json.Unmarshal([]byte(imagesManifest), &imagesMapJSON); err != nil {
-			fmt.Fprintf(os.Stderr, "merge-manifests: ::error::Invalid JSON in images_manifest output\n")
-			fmt.Fprintf(os.Stderr, "merge-manifests: Raw content:\n%s\n", imagesManifest)
-			fmt.Fprintf(os.Stderr, "merge-manifests: Error: %v\n", err)
-			os.Exit(1)
-		}
+	// Marshal to JSON and write to stdout
+	jsonBytes, err := manifest.Marshal(m)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "merge-manifests: error: marshaling merged manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Write JSON to stdout (progress messages go to stderr)
+	fmt.Println(string(jsonBytes))
+	fmt.Fprintln(os.Stderr, "✅ Merged manifest complete")
+}
+
+// parseFragmentSpec splits a kind=path -fragment value.
+func parseFragmentSpec(spec string) (fragmentKind, string, error) {
+	kind, path, ok := strings.Cut(spec, "=")
+	if !ok || path == "" {
+		return "", "", fmt.Errorf("fragment %q must be kind=path", spec)
+	}
+	switch k := fragmentKind(kind); k {
+	case kindManifest, kindAssets, kindImages:
+		return k, path, nil
+	default:
+		return "", "", fmt.Errorf("fragment %q: kind must be manifest, assets or images", spec)
+	}
+}
 
-		// Convert each JSON value to proto Image message
-		images := manifest.GetImages()
-		if images == nil {
-			images = make(map[string]*pb.Image)
-			manifest.SetImages(images)
+// loadFragment reads the fragment described by spec. A path of "-" reads
+// stdin, which may only be used once.
+func loadFragment(spec string, stdin io.Reader, stdinUsed *bool) (fragment, error) {
+	kind, path, err := parseFragmentSpec(spec)
+	if err != nil {
+		return fragment{}, err
+	}
+	if path == "-" {
+		if *stdinUsed {
+			return fragment{}, errors.New("only one fragment can be read from stdin")
+		}
+		*stdinUsed = true
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fragment{}, fmt.Errorf("reading stdin: %w", err)
 		}
+		return fragment{kind: kind, source: "stdin", data: data}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fragment{}, fmt.Errorf("reading fragment: %w", err)
+	}
+	return fragment{kind: kind, source: path, data: data}, nil
+}
+
+// merger combines fragments into one Manifest.
+type merger struct {
+	policy conflictPolicy
+	logf   func(format string, args ...any)
+	// requireUniformAttestations fails the merge when attested assets carry
+	// different predicate types, instead of warning.
+	requireUniformAttestations bool
+	// origins maps each merged field ("name") and entry ("assets[linux-amd64]")
+	// to the fragment whose value was kept.
+	origins map[string]string
+}
 
-		unmarshalOpts := protojson.UnmarshalOptions{
-			DiscardUnknown: true,
+// merge applies fragments in order, resolving repeated keys and differing
+// field values with the merger's policy. Empty fragments ("" or "{}") are skipped.
+func (mg *merger) merge(fragments []fragment) (*pb.Manifest, error) {
+	mg.origins = map[string]string{}
+	m := pb.Manifest_builder{
+		Assets: map[string]*pb.Asset{},
+		Images: map[string]*pb.Image{},
+	}.Build()
+
+	for _, f := range fragments {
+		trimmed := bytes.TrimSpace(f.data)
+		if len(trimmed) == 0 || string(trimmed) == "{}" {
+			mg.logf("ℹ️  Skipping empty %s fragment from %s", f.kind, f.source)
+			continue
 		}
-		hasAttestedImage := false
-		for key, imageJSON := range imagesMapJSON {
-			image := &pb.Image{}
-			if err := unmarshalOpts.Unmarshal(imageJSON, image); err != nil {
-				fmt.Fprintf(os.Stderr, "merge-manifests: error: unmarshaling image %s: %v\n", key, err)
-				os.Exit(1)
-			}
-			images[key] = image
-			if image.GetIsIndex() {
-				hasAttestedImage = true
-			}
+		var err error
+		switch f.kind {
+		case kindManifest:
+			err = mg.mergeManifest(m, f)
+		case kindAssets:
+			err = mg.mergeAssets(m, f)
+		case kindImages:
+			err = mg.mergeImages(m, f)
 		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.GetVersion() == "" {
+		return nil, errors.New("merged manifest has no version (is a manifest fragment missing?)")
+	}
 
-		if hasAttestedImage {
-			// Set manifest-level image attestation descriptor.
-			// OCI index images use referrers for attestation discovery, so bundle_href is omitted.
-			attestationType := AttestationTypeInTotoV1
-			predicateType := PredicateTypeSLSAProvenanceV1
-			manifest.SetImageAttestation(pb.AttestationDescriptor_builder{
-				AttestationType: &attestationType,
-				PredicateType:   &predicateType,
-			}.Build())
+	mg.logf("Merged entries:")
+	for _, name := range manifest.SortedKeys(mg.origins) {
+		mg.logf("  %s ← %s", name, mg.origins[name])
+	}
+
+	// OCI index images use referrers for attestation discovery, so bundle_href is omitted.
+	// Unlike asset attestations, the predicate type is not derived: image attestations
+	// live in the registry and no fragment records them, so this assumes the SLSA
+	// provenance the docker job pushes for every index.
+	for _, image := range m.GetImages() {
+		if image.GetIsIndex() {
+			m.SetImageAttestation(manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, ""))
+			break
 		}
+	}
+	// Summarize the predicate types the assets actually carry
+	if summary := manifest.SummarizeAssetAttestations(m.GetAssets()); len(summary) > 0 {
+		m.SetAssetAttestations(summary)
+		m.SetAssetAttestation(summary[0])
+		for _, att := range summary {
+			mg.logf("✅ Assets carry %s attestations", att.GetPredicateType())
+		}
+	}
+	if problems := manifest.CheckAttestationSets(m.GetAssets()); len(problems) > 0 {
+		if mg.requireUniformAttestations {
+			return nil, fmt.Errorf("assets carry different attestation sets: %s", strings.Join(problems, "; "))
+		}
+		for _, p := range problems {
+			mg.logf("merge-manifests: ::warning::%s", p)
+		}
+	}
+	return m, nil
+}
 
-		fmt.Fprintf(os.Stderr, "✅ Added %d images to manifest\n", len(images))
-	} else {
-		fmt.Fprintln(os.Stderr, "ℹ️  No images to add to manifest (docker job may have been skipped if no Dockerfile)")
+func (mg *merger) mergeManifest(m *pb.Manifest, f fragment) error {
+	part := &pb.Manifest{}
+	if err := manifest.Unmarshal(f.data, part); err != nil {
+		return fmt.Errorf("invalid JSON in %s (manifest fragment): %w", f.source, err)
 	}
 
-	// Merge Windows assets if present
-	if windowsManifest != "" && windowsManifest != "{}" {
-		// Windows manifest format: { "windows-amd64": { "filename": "...", ... }, "windows-amd64-msi": { ... } }
-		var windowsMapJSON map[string]json.RawMessage
-		if err := json.Unmarshal([]byte(windowsManifest), &windowsMapJSON); err != nil {
-			fmt.Fprintf(os.Stderr, "merge-manifests: ::error::Invalid JSON in windows_manifest output\n")
-			fmt.Fprintf(os.Stderr, "merge-manifests: Raw content:\n%s\n", windowsManifest)
-			fmt.Fprintf(os.Stderr, "merge-manifests: Error: %v\n", err)
-			os.Exit(1)
+	// Copy every non-empty scalar and message field; zero values written by
+	// EmitUnpopulated must not erase fields set by an earlier fragment.
+	// Repeating an identical value is not a conflict.
+	dst := m.ProtoReflect()
+	var err error
+	part.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsMap() || isZeroValue(fd, v) {
+			return true
+		}
+		if dst.Has(fd) && dst.Get(fd).Equal(v) {
+			return true
+		}
+		var replace bool
+		if replace, err = mg.resolve(string(fd.Name()), f.source, false); err != nil {
+			return false
 		}
+		if replace {
+			dst.Set(fd, v)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if err := mergeEntries(mg, "assets", m.GetAssets(), part.GetAssets(), f.source); err != nil {
+		return err
+	}
+	if err := mergeEntries(mg, "images", m.GetImages(), part.GetImages(), f.source); err != nil {
+		return err
+	}
+	mg.logf("✅ Merged manifest fragment from %s (%d assets, %d images)", f.source, len(part.GetAssets()), len(part.GetImages()))
+	return nil
+}
 
-		// Get or create assets map
-		assets := manifest.GetAssets()
-		if assets == nil {
-			assets = make(map[string]*pb.Asset)
-			manifest.SetAssets(assets)
+func (mg *merger) mergeAssets(m *pb.Manifest, f fragment) error {
+	entries, err := decodeMap(f)
+	if err != nil {
+		return err
+	}
+	assets := make(map[string]*pb.Asset, len(entries))
+	for key, data := range entries {
+		asset := &pb.Asset{}
+		if err := manifest.Unmarshal(data, asset); err != nil {
+			return fmt.Errorf("unmarshaling asset %s from %s: %w", key, f.source, err)
 		}
+		assets[key] = asset
+	}
+	if err := mergeEntries(mg, "assets", m.GetAssets(), assets, f.source); err != nil {
+		return err
+	}
+	mg.logf("✅ Added %d assets from %s", len(entries), f.source)
+	return nil
+}
 
-		unmarshalOpts := protojson.UnmarshalOptions{
-			DiscardUnknown: true,
+func (mg *merger) mergeImages(m *pb.Manifest, f fragment) error {
+	entries, err := decodeMap(f)
+	if err != nil {
+		return err
+	}
+	images := make(map[string]*pb.Image, len(entries))
+	for key, data := range entries {
+		image := &pb.Image{}
+		if err := manifest.Unmarshal(data, image); err != nil {
+			return fmt.Errorf("unmarshaling image %s from %s: %w", key, f.source, err)
 		}
-		for key, assetJSON := range windowsMapJSON {
-			asset := &pb.Asset{}
-			if err := unmarshalOpts.Unmarshal(assetJSON, asset); err != nil {
-				fmt.Fprintf(os.Stderr, "merge-manifests: error: unmarshaling Windows asset %s: %v\n", key, err)
-				os.Exit(1)
+		images[key] = image
+	}
+	if err := mergeEntries(mg, "images", m.GetImages(), images, f.source); err != nil {
+		return err
+	}
+	mg.logf("✅ Added %d images from %s", len(entries), f.source)
+	return nil
+}
+
+// mergeEntries adds the entries of src from source to dst, in key order so
+// that conflict errors are reported deterministically.
+func mergeEntries[V proto.Message](mg *merger, section string, dst, src map[string]V, source string) error {
+	for _, key := range manifest.SortedKeys(src) {
+		value := src[key]
+		if existing, ok := dst[key]; ok {
+			replace, err := mg.resolve(section+"["+key+"]", source, proto.Equal(existing, value))
+			if err != nil {
+				return err
+			}
+			if !replace {
+				continue
 			}
-			assets[key] = asset
+		} else {
+			mg.origins[section+"["+key+"]"] = source
 		}
-
-		fmt.Fprintf(os.Stderr, "✅ Added %d Windows assets to manifest\n", len(windowsMapJSON))
-	} else {
-		fmt.Fprintln(os.Stderr, "ℹ️  No Windows assets to add to manifest")
+		dst[key] = value
 	}
+	return nil
+}
 
-	// Set manifest-level asset attestation descriptor if any assets have attestations
-	hasAssetAttestations := false
-	for _, asset := range manifest.GetAssets() {
-		if len(asset.GetAttestations()) > 0 {
-			hasAssetAttestations = true
-			break
-		}
+// resolve applies the conflict policy to a second value for name from
+// source, and reports whether it should replace the current one.
+func (mg *merger) resolve(name, source string, identical bool) (bool, error) {
+	prev, seen := mg.origins[name]
+	if !seen {
+		mg.origins[name] = source
+		return true, nil
 	}
-	if hasAssetAttestations {
-		attestationType := AttestationTypeInTotoV1
-		predicateType := PredicateTypeSLSAProvenanceV1
-		manifest.SetAssetAttestation(pb.AttestationDescriptor_builder{
-			AttestationType: &attestationType,
-			PredicateType:   &predicateType,
-		}.Build())
-		fmt.Fprintln(os.Stderr, "✅ Set asset_attestation descriptor")
+	switch mg.policy {
+	case policyPreferFirst:
+		if !identical {
+			mg.logf("merge-manifests: warning: %s from %s ignored; keeping the value from %s", name, source, prev)
+		}
+		return false, nil
+	case policyPreferLast:
+		if !identical {
+			mg.logf("merge-manifests: warning: %s from %s replaces the value from %s", name, source, prev)
+		}
+		mg.origins[name] = source
+		return true, nil
+	case policyRequireIdentical:
+		if !identical {
+			return false, fmt.Errorf("%s differs between %s and %s (on-conflict=require-identical)", name, prev, source)
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s is provided by both %s and %s (set -on-conflict to resolve)", name, prev, source)
 	}
+}
 
-	// Marshal to JSON and write to stdout
-	jsonBytes, err := marshalOpts.Marshal(manifest)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "merge-manifests: error: marshaling merged manifest: %v\n", err)
-		os.Exit(1)
+// decodeMap splits an assets or images fragment into its entries.
+// The JSON format is: { "key": { ... }, ... }
+func decodeMap(f fragment) (map[string]json.RawMessage, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(f.data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s (%s fragment): %w", f.source, f.kind, err)
 	}
+	return entries, nil
+}
 
-	// Write JSON to stdout (progress messages go to stderr)
-	fmt.Println(string(jsonBytes))
-	fmt.Fprintln(os.Stderr, "✅ Merged manifest complete")
+func isZeroValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+	switch {
+	case fd.IsList():
+		return v.List().Len() == 0
+	case fd.Message() != nil:
+		return proto.Size(v.Message().Interface()) == 0
+	default:
+		return v.Equal(fd.Default())
+	}
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const binariesFragment = `{
+  "version": "2",
+  "name": "baton-example",
+  "org": "ConductorOne",
+  "semver": "v0.1.2",
+  "releasedAt": "2025-01-02T03:04:05Z",
+  "assets": {
+    "linux-amd64": {"filename": "baton-example-v0.1.2-linux-amd64.tar.gz", "sha256": "aa", "attestations": [{"predicateType": "https://slsa.dev/provenance/v1"}]}
+  },
+  "images": {},
+  "signatureHref": "https://example.com/manifest.json.sig"
+}`
+
+func newTestMerger(t *testing.T) *merger {
+	return &merger{logf: t.Logf}
+}
+
+func TestMergeFragmentKinds(t *testing.T) {
+	fragments := []fragment{
+		{kindManifest, "binaries", []byte(binariesFragment)},
+		{kindAssets, "windows", []byte(`{"windows-amd64": {"filename": "baton-example-v0.1.2-windows-amd64.zip"}}`)},
+		{kindImages, "images", []byte(`{"ecrPublic": {"ref": "public.ecr.aws/c1/baton-example:0.1.2", "isIndex": true}}`)},
+		// A partial manifest written with EmitUnpopulated must not blank fields set earlier.
+		{kindManifest, "helm", []byte(`{"version": "", "name": "", "semver": "", "signatureHref": "", "assets": {"helm-chart": {"filename": "baton-example-0.1.2.tgz"}}}`)},
+		{kindImages, "empty", []byte("{}")},
+	}
+	m, err := newTestMerger(t).merge(fragments)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	if m.GetVersion() != "2" || m.GetName() != "baton-example" || m.GetSignatureHref() == "" {
+		t.Errorf("scalar fields = %q %q %q", m.GetVersion(), m.GetName(), m.GetSignatureHref())
+	}
+	if m.GetReleasedAt().GetSeconds() == 0 {
+		t.Error("released_at was cleared")
+	}
+	for _, key := range []string{"linux-amd64", "windows-amd64", "helm-chart"} {
+		if _, ok := m.GetAssets()[key]; !ok {
+			t.Errorf("asset %s missing", key)
+		}
+	}
+	if _, ok := m.GetImages()["ecrPublic"]; !ok {
+		t.Error("image ecrPublic missing")
+	}
+	if !m.HasImageAttestation() || !m.HasAssetAttestation() {
+		t.Error("manifest-level attestation descriptors not set")
+	}
+}
+
+func TestMergeConflictPolicies(t *testing.T) {
+	first := `{"windows-amd64": {"filename": "a.zip", "href": "https://example.com/a.zip"}}`
+	second := `{"windows-amd64": {"filename": "b.zip", "href": "https://example.com/b.zip"}}`
+	fragments := func(windows ...string) []fragment {
+		fs := []fragment{{kindManifest, "binaries.json", []byte(binariesFragment)}}
+		for i, data := range windows {
+			fs = append(fs, fragment{kindAssets, []string{"first.json", "second.json"}[i], []byte(data)})
+		}
+		return fs
+	}
+
+	for _, tc := range []struct {
+		policy   conflictPolicy
+		windows  []string
+		wantHref string
+		wantFrom string
+		wantErr  string
+	}{
+		{policy: policyError, windows: []string{first, first}, wantErr: "assets[windows-amd64] is provided by both first.json and second.json"},
+		{policy: policyRequireIdentical, windows: []string{first, first}, wantHref: "https://example.com/a.zip", wantFrom: "first.json"},
+		{policy: policyRequireIdentical, windows: []string{first, second}, wantErr: "differs between first.json and second.json"},
+		{policy: policyPreferFirst, windows: []string{first, second}, wantHref: "https://example.com/a.zip", wantFrom: "first.json"},
+		{policy: policyPreferLast, windows: []string{first, second}, wantHref: "https://example.com/b.zip", wantFrom: "second.json"},
+	} {
+		mg := &merger{policy: tc.policy, logf: t.Logf}
+		m, err := mg.merge(fragments(tc.windows...))
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tc.policy, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: merge: %v", tc.policy, err)
+			continue
+		}
+		if got := m.GetAssets()["windows-amd64"].GetHref(); got != tc.wantHref {
+			t.Errorf("%s: href = %q, want %q", tc.policy, got, tc.wantHref)
+		}
+		if got := mg.origins["assets[windows-amd64]"]; got != tc.wantFrom {
+			t.Errorf("%s: origin = %q, want %q", tc.policy, got, tc.wantFrom)
+		}
+		if got := mg.origins["assets[linux-amd64]"]; got != "binaries.json" {
+			t.Errorf("%s: linux-amd64 origin = %q", tc.policy, got)
+		}
+	}
+}
+
+func TestMergeScalarConflicts(t *testing.T) {
+	same := `{"version": "2", "name": "baton-example"}`
+	renamed := `{"version": "2", "name": "baton-other"}`
+
+	// Repeating an identical field value is never a conflict.
+	mg := &merger{policy: policyError, logf: t.Logf}
+	if _, err := mg.merge([]fragment{{kindManifest, "binaries.json", []byte(binariesFragment)}, {kindManifest, "helm.json", []byte(same)}}); err != nil {
+		t.Fatalf("identical fields: %v", err)
+	}
+	if got := mg.origins["name"]; got != "binaries.json" {
+		t.Errorf("name origin = %q", got)
+	}
+
+	_, err := mg.merge([]fragment{{kindManifest, "binaries.json", []byte(binariesFragment)}, {kindManifest, "helm.json", []byte(renamed)}})
+	if err == nil || !strings.Contains(err.Error(), "name is provided by both binaries.json and helm.json") {
+		t.Fatalf("err = %v, want a name conflict", err)
+	}
+
+	mg.policy = policyPreferLast
+	m, err := mg.merge([]fragment{{kindManifest, "binaries.json", []byte(binariesFragment)}, {kindManifest, "helm.json", []byte(renamed)}})
+	if err != nil || m.GetName() != "baton-other" {
+		t.Fatalf("prefer-last name = %q, %v", m.GetName(), err)
+	}
+}
+
+func TestMergeSummarizesAssetAttestations(t *testing.T) {
+	sbomOnly := `{"version": "2", "assets": {"linux-amd64": {"filename": "a.tar.gz", "attestations": [{"predicateType": "https://spdx.dev/Document"}]}}}`
+	m, err := newTestMerger(t).merge([]fragment{{kindManifest, "binaries", []byte(sbomOnly)}})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if got := m.GetAssetAttestation().GetPredicateType(); got != "https://spdx.dev/Document" {
+		t.Errorf("asset_attestation = %q, want the SBOM predicate type", got)
+	}
+	if len(m.GetAssetAttestations()) != 1 {
+		t.Errorf("asset_attestations = %v", m.GetAssetAttestations())
+	}
+
+	// The Windows zip has provenance only, while linux-amd64 has an SBOM only.
+	fragments := []fragment{
+		{kindManifest, "binaries", []byte(sbomOnly)},
+		{kindAssets, "windows", []byte(`{"windows-amd64": {"attestations": [{"predicateType": "https://slsa.dev/provenance/v1"}]}}`)},
+	}
+	m, err = newTestMerger(t).merge(fragments)
+	if err != nil {
+		t.Fatalf("merge with differing sets: %v", err)
+	}
+	if len(m.GetAssetAttestations()) != 2 || m.GetAssetAttestation().GetPredicateType() != "https://slsa.dev/provenance/v1" {
+		t.Errorf("asset attestations = %v, %v", m.GetAssetAttestation(), m.GetAssetAttestations())
+	}
+	mg := &merger{policy: policyError, logf: t.Logf, requireUniformAttestations: true}
+	if _, err := mg.merge(fragments); err == nil || !strings.Contains(err.Error(), "windows-amd64: has no https://spdx.dev/Document") {
+		t.Fatalf("err = %v, want an attestation set error", err)
+	}
+}
+
+func TestMergeRequiresVersion(t *testing.T) {
+	_, err := newTestMerger(t).merge([]fragment{{kindAssets, "windows", []byte(`{"windows-amd64": {}}`)}})
+	if err == nil || !strings.Contains(err.Error(), "no version") {
+		t.Fatalf("err = %v, want missing version", err)
+	}
+}
+
+func TestMergeReportsFragmentSource(t *testing.T) {
+	_, err := newTestMerger(t).merge([]fragment{{kindImages, "images.json", []byte(`["not", "a", "map"]`)}})
+	if err == nil || !strings.Contains(err.Error(), "images.json") {
+		t.Fatalf("err = %v, want the fragment source", err)
+	}
+}
+
+func TestLoadFragment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windows.json")
+	if err := os.WriteFile(path, []byte(`{"windows-amd64": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdinUsed := false
+	f, err := loadFragment("assets="+path, nil, &stdinUsed)
+	if err != nil || f.kind != kindAssets || f.source != path {
+		t.Fatalf("file fragment = %+v, %v", f, err)
+	}
+
+	f, err = loadFragment("manifest=-", strings.NewReader(binariesFragment), &stdinUsed)
+	if err != nil || f.source != "stdin" || string(f.data) != binariesFragment {
+		t.Fatalf("stdin fragment = %+v, %v", f, err)
+	}
+	if _, err := loadFragment("images=-", strings.NewReader("{}"), &stdinUsed); err == nil {
+		t.Fatal("expected an error for a second stdin fragment")
+	}
+
+	for _, spec := range []string{"windows.json", "charts=chart.json", "assets="} {
+		if _, _, err := parseFragmentSpec(spec); err == nil {
+			t.Errorf("parseFragmentSpec(%q) succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// errPrerelease is returned by promote when the candidate is a prerelease and prereleases are not allowed.
+var errPrerelease = errors.New("prerelease versions are not promoted to stable")
+
+func main() {
+	var (
+		manifestPath      string
+		stablePath        string
+		outputPath        string
+		includePrerelease bool
+	)
+	flag.StringVar(&manifestPath, "manifest", "", "Path to merged manifest.json for the release being promoted (required)")
+	flag.StringVar(&stablePath, "stable", "", "Path to the current stable.json (optional, a missing file means no stable release yet)")
+	flag.StringVar(&outputPath, "output", "", "Path to write the new stable.json (optional, defaults to stdout)")
+	flag.BoolVar(&includePrerelease, "include-prerelease", false, "Allow promoting prerelease versions (e.g., v1.2.0-rc.1)")
+	flag.Parse()
+
+	if manifestPath == "" {
+		fmt.Fprintf(os.Stderr, "promote-stable: error: manifest is required\n")
+		os.Exit(1)
+	}
+
+	m, err := manifest.ReadManifest(manifestPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "promote-stable: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var current *pb.Stable
+	if stablePath != "" {
+		current, err = manifest.ReadStable(stablePath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(os.Stderr, "ℹ️  No existing stable.json at %s, promoting first stable release\n", stablePath)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "promote-stable: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	stable, err := promote(current, m, includePrerelease, time.Now().UTC())
+	if errors.Is(err, errPrerelease) {
+		// Not a failure: prerelease tags run the same workflow but must leave stable untouched.
+		fmt.Fprintf(os.Stderr, "ℹ️  Skipping %s: %v (use -include-prerelease to override)\n", m.GetSemver(), err)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "promote-stable: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	jsonBytes, err := manifest.Marshal(stable)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "promote-stable: error: marshaling stable: %v\n", err)
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		// Write JSON to stdout (progress messages go to stderr)
+		fmt.Println(string(jsonBytes))
+	} else if err := os.WriteFile(outputPath, append(jsonBytes, '\n'), 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "promote-stable: error: writing stable: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Promoted %s to stable\n", m.GetSemver())
+}
+
+// promote returns the Stable document pointing at m.
+// It refuses to move stable to a lower semver than current and returns errPrerelease
+// for prerelease candidates unless includePrerelease is set. Re-promoting the
+// current stable version is allowed so that reruns of a release are idempotent.
+func promote(current *pb.Stable, m *pb.Manifest, includePrerelease bool, now time.Time) (*pb.Stable, error) {
+	if m.GetVersion() == "" {
+		return nil, errors.New("manifest is empty")
+	}
+	candidate := m.GetSemver()
+	if !semver.IsValid(candidate) {
+		return nil, fmt.Errorf("manifest semver %q is not a valid semantic version", candidate)
+	}
+	if semver.Prerelease(candidate) != "" && !includePrerelease {
+		return nil, errPrerelease
+	}
+
+	if current.GetManifest() != nil {
+		existing := current.GetManifest().GetSemver()
+		if !semver.IsValid(existing) {
+			return nil, fmt.Errorf("current stable semver %q is not a valid semantic version", existing)
+		}
+		if semver.Compare(candidate, existing) < 0 {
+			return nil, fmt.Errorf("refusing to move stable backwards from %s to %s", existing, candidate)
+		}
+		if current.GetManifest().GetOrg() != m.GetOrg() || current.GetManifest().GetName() != m.GetName() {
+			return nil, fmt.Errorf("current stable is for %s/%s, manifest is for %s/%s",
+				current.GetManifest().GetOrg(), current.GetManifest().GetName(), m.GetOrg(), m.GetName())
+		}
+	}
+
+	version := manifest.StableSchemaVersion
+	return pb.Stable_builder{
+		Version:   &version,
+		UpdatedAt: timestamppb.New(now),
+		Manifest:  m,
+	}.Build(), nil
+}
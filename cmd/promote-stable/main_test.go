@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func TestPromoteFirstRelease(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	stable, err := promote(nil, testManifest("v0.1.0"), false, now)
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if stable.GetVersion() != manifest.StableSchemaVersion {
+		t.Fatalf("version = %q, want %q", stable.GetVersion(), manifest.StableSchemaVersion)
+	}
+	if !stable.GetUpdatedAt().AsTime().Equal(now) {
+		t.Fatalf("updated_at = %v, want %v", stable.GetUpdatedAt().AsTime(), now)
+	}
+	if stable.GetManifest().GetSemver() != "v0.1.0" {
+		t.Fatalf("manifest semver = %q", stable.GetManifest().GetSemver())
+	}
+}
+
+func TestPromoteMovesForward(t *testing.T) {
+	current := testStable("v0.1.9")
+	stable, err := promote(current, testManifest("v0.1.10"), false, time.Now())
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if stable.GetManifest().GetSemver() != "v0.1.10" {
+		t.Fatalf("manifest semver = %q, want v0.1.10", stable.GetManifest().GetSemver())
+	}
+}
+
+func TestPromoteAllowsSameVersion(t *testing.T) {
+	if _, err := promote(testStable("v0.1.9"), testManifest("v0.1.9"), false, time.Now()); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+}
+
+func TestPromoteRefusesBackwards(t *testing.T) {
+	_, err := promote(testStable("v0.2.0"), testManifest("v0.1.99"), false, time.Now())
+	if err == nil {
+		t.Fatal("expected error moving stable backwards")
+	}
+}
+
+func TestPromoteSkipsPrerelease(t *testing.T) {
+	_, err := promote(testStable("v0.1.0"), testManifest("v0.2.0-rc.1"), false, time.Now())
+	if !errors.Is(err, errPrerelease) {
+		t.Fatalf("err = %v, want errPrerelease", err)
+	}
+
+	stable, err := promote(testStable("v0.1.0"), testManifest("v0.2.0-rc.1"), true, time.Now())
+	if err != nil {
+		t.Fatalf("promote with prerelease: %v", err)
+	}
+	if stable.GetManifest().GetSemver() != "v0.2.0-rc.1" {
+		t.Fatalf("manifest semver = %q", stable.GetManifest().GetSemver())
+	}
+}
+
+func TestPromoteRejectsInvalidSemver(t *testing.T) {
+	if _, err := promote(nil, testManifest("0.1.0"), false, time.Now()); err == nil {
+		t.Fatal("expected error for semver without v prefix")
+	}
+}
+
+func TestPromoteRejectsDifferentRepo(t *testing.T) {
+	current := testStable("v0.1.0")
+	manifest := testManifest("v0.2.0")
+	manifest.SetName("baton-other")
+	if _, err := promote(current, manifest, false, time.Now()); err == nil {
+		t.Fatal("expected error promoting a manifest for a different repo")
+	}
+}
+
+func testManifest(semver string) *pb.Manifest {
+	return pb.Manifest_builder{
+		Version: strPtr("2"),
+		Name:    strPtr("baton-example"),
+		Org:     strPtr("ConductorOne"),
+		Semver:  &semver,
+	}.Build()
+}
+
+func testStable(semver string) *pb.Stable {
+	return pb.Stable_builder{
+		Version:  strPtr(manifest.StableSchemaVersion),
+		Manifest: testManifest(semver),
+	}.Build()
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -2,82 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
-	"google.golang.org/protobuf/encoding/protojson"
-
-	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	"github.com/ConductorOne/github-workflows/internal/registry"
 )
 
-// RecordReleaseRequest is the JSON body sent to the registry API.
-type RecordReleaseRequest struct {
-	Org            string                   `json:"org"`
-	Name           string                   `json:"name"`
-	Version        string                   `json:"version"`
-	RepositoryURL  string                   `json:"repositoryUrl"`
-	CommitSha      string                   `json:"commitSha"`
-	WorkflowRunID  string                   `json:"workflowRunId"`
-	Documentation  string                   `json:"documentation,omitempty"`
-	Changelog      string                   `json:"changelog,omitempty"`
-	ConfigSchema   string                   `json:"configSchema,omitempty"`
-	Capabilities   string                   `json:"capabilities,omitempty"`
-	SignatureURL   string                   `json:"signatureUrl,omitempty"`
-	CertificateURL string                   `json:"certificateUrl,omitempty"`
-	Assets         map[string]*ReleaseAsset `json:"assets,omitempty"`
-	Images         map[string]*ReleaseImage `json:"images,omitempty"`
-	ReleasedAt     string                   `json:"releasedAt,omitempty"`
-}
-
-// ReleaseAsset is the transformed asset for the registry API.
-type ReleaseAsset struct {
-	Platform       string                `json:"platform"`
-	Filename       string                `json:"filename"`
-	MediaType      string                `json:"mediaType"`
-	SizeBytes      int64                 `json:"sizeBytes"`
-	Sha256         string                `json:"sha256"`
-	DownloadURL    string                `json:"downloadUrl"`
-	SignatureURL   string                `json:"signatureUrl,omitempty"`
-	CertificateURL string                `json:"certificateUrl,omitempty"`
-	SbomURL        string                `json:"sbomUrl,omitempty"`
-	Attestations   []*ReleaseAttestation `json:"attestations,omitempty"`
-}
-
-// ReleaseImage is the transformed image for the registry API.
-type ReleaseImage struct {
-	Ref          string                `json:"ref"`
-	Digest       string                `json:"digest"`
-	Platform     string                `json:"platform"`
-	Attestations []*ReleaseAttestation `json:"attestations,omitempty"`
-}
-
-// ReleaseAttestation is the registry API attestation shape.
-type ReleaseAttestation struct {
-	Type   string `json:"type"`
-	URL    string `json:"url,omitempty"`
-	Digest string `json:"digest,omitempty"`
-}
-
-// authTransport adds a Bearer token to every outgoing request.
-type authTransport struct {
-	token string
-	base  http.RoundTripper
-}
-
-func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req = req.Clone(req.Context())
-	req.Header.Set("Authorization", "Bearer "+t.token)
-	req.Header.Set("Content-Type", "application/json")
-	return t.base.RoundTrip(req)
-}
-
 func main() {
 	var (
 		manifestPath     string
@@ -88,11 +28,12 @@ func main() {
 		repositoryURL    string
 		commitSha        string
 		workflowRunID    string
-		registryURL      string
 		changelogPath    string
 		configSchemaPath string
 		capabilitiesPath string
-		token            string
+		outputRequest    string
+		verifyRevision   bool
+		opts             sendOptions
 	)
 
 	flag.StringVar(&manifestPath, "manifest", "", "Path to merged manifest.json file (required)")
@@ -103,13 +44,22 @@ func main() {
 	flag.StringVar(&repositoryURL, "repository-url", "", "Full repository URL (required)")
 	flag.StringVar(&commitSha, "commit-sha", "", "Git commit SHA (required)")
 	flag.StringVar(&workflowRunID, "workflow-run-id", "", "GitHub Actions workflow run ID (required)")
-	flag.StringVar(&registryURL, "registry-url", "", "Registry API base URL (required)")
 	flag.StringVar(&changelogPath, "changelog", "", "Path to a file containing release notes (optional)")
 	flag.StringVar(&configSchemaPath, "config-schema", "", "Path to config_schema.json file (optional)")
 	flag.StringVar(&capabilitiesPath, "capabilities", "", "Path to baton_capabilities.json file (optional)")
 	var releasedAt string
 	flag.StringVar(&releasedAt, "released-at", "", "Release publish timestamp in RFC 3339 format (optional, defaults to server time)")
-	flag.StringVar(&token, "token", "", "Bearer token (or set REGISTRY_API_TOKEN env var)")
+	flag.BoolVar(&verifyRevision, "verify-build-revision", false, "Fail unless every archived binary's vcs.revision equals -commit-sha and vcs.modified is false")
+	flag.StringVar(&outputRequest, "output-request", "", "Write the JSON request body to this file, for use with the replay mode (optional)")
+	opts.register(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: record-release [flags]\n       record-release replay -request FILE [flags]\n\n")
+		flag.PrintDefaults()
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replay(os.Args[2:])
+		return
+	}
 	flag.Parse()
 
 	// Validate required flags
@@ -135,7 +85,7 @@ func main() {
 	if workflowRunID == "" {
 		missing = append(missing, "-workflow-run-id")
 	}
-	if registryURL == "" {
+	if opts.registryURL == "" {
 		missing = append(missing, "-registry-url")
 	}
 	if len(missing) > 0 {
@@ -144,29 +94,28 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Resolve token: flag > env var
-	if token == "" {
-		token = os.Getenv("REGISTRY_API_TOKEN")
-	}
-	if token == "" {
-		fmt.Fprintf(os.Stderr, "record-release: error: bearer token required (use -token flag or REGISTRY_API_TOKEN env var)\n")
+	if err := opts.resolveToken(); err != nil {
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Read and parse manifest using protojson (same pattern as merge-manifests)
-	manifestBytes, err := os.ReadFile(manifestPath)
+	// Read and parse manifest (same encoding as merge-manifests)
+	m, err := manifest.ReadManifest(manifestPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: reading manifest: %v\n", err)
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
 		os.Exit(1)
 	}
 
-	manifest := &pb.Manifest{}
-	unmarshalOpts := protojson.UnmarshalOptions{
-		DiscardUnknown: true,
-	}
-	if err := unmarshalOpts.Unmarshal(manifestBytes, manifest); err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: parsing manifest: %v\n", err)
-		os.Exit(1)
+	// Binaries built from another checkout must not be recorded under this commit
+	if verifyRevision {
+		problems := manifest.VerifyManifestRevisions(m, commitSha)
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "::error::%s\n", p)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "record-release: error: %d assets were not built from commit %s\n", len(problems), commitSha)
+			os.Exit(1)
+		}
 	}
 
 	// Read optional documentation
@@ -215,11 +164,11 @@ func main() {
 		}
 	}
 
-	assets := transformAssets(manifest)
-	images := transformImages(manifest)
+	assets := registry.TransformAssets(m)
+	images := registry.TransformImages(m)
 
 	// Build request body
-	req := &RecordReleaseRequest{
+	req := &registry.RecordReleaseRequest{
 		Org:            org,
 		Name:           name,
 		Version:        version,
@@ -230,8 +179,8 @@ func main() {
 		Changelog:      changelog,
 		ConfigSchema:   configSchema,
 		Capabilities:   capabilities,
-		SignatureURL:   manifest.GetSignatureHref(),
-		CertificateURL: manifest.GetCertificateHref(),
+		SignatureURL:   m.GetSignatureHref(),
+		CertificateURL: m.GetCertificateHref(),
 		Assets:         assets,
 		Images:         images,
 		ReleasedAt:     releasedAt,
@@ -243,37 +192,129 @@ func main() {
 		os.Exit(1)
 	}
 
-	// POST to registry API
-	baseURL, err := url.Parse(registryURL)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: parsing registry URL: %v\n", err)
+	// Save the exact body before sending so a failed ingest can be replayed
+	if outputRequest != "" {
+		if err := os.WriteFile(outputRequest, bodyBytes, 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "record-release: error: writing request: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "record-release: wrote request body to %s\n", outputRequest)
+	}
+
+	if err := opts.send(context.Background(), bodyBytes, registry.IdempotencyKey(org, name, version, commitSha), version); err != nil {
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
 		os.Exit(1)
 	}
-	endpoint := baseURL.JoinPath("/api/v1/ingest/release")
-	httpReq, err := http.NewRequest(http.MethodPost, endpoint.String(), bytes.NewReader(bodyBytes))
+}
+
+// replay resends a request body saved with -output-request.
+func replay(args []string) {
+	fs := flag.NewFlagSet("record-release replay", flag.ExitOnError)
+	var (
+		requestPath string
+		opts        sendOptions
+	)
+	fs.StringVar(&requestPath, "request", "", "Path to a request body saved with -output-request (required)")
+	opts.register(fs)
+	_ = fs.Parse(args)
+
+	if requestPath == "" || opts.registryURL == "" {
+		fmt.Fprintf(os.Stderr, "record-release: error: replay requires -request and -registry-url\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := opts.resolveToken(); err != nil {
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	body, req, err := readRequest(requestPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: creating HTTP request: %v\n", err)
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := opts.send(context.Background(), body, registry.IdempotencyKey(req.Org, req.Name, req.Version, req.CommitSha), req.Version); err != nil {
+		fmt.Fprintf(os.Stderr, "record-release: error: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &authTransport{
-			token: token,
-			base:  http.DefaultTransport,
-		},
+// readRequest reads a saved request body and decodes enough of it to derive
+// the idempotency key. The body is resent byte for byte.
+func readRequest(path string) ([]byte, *registry.RecordReleaseRequest, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading request: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	req := &registry.RecordReleaseRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, nil, fmt.Errorf("parsing request %s: %w", path, err)
+	}
+	if req.Org == "" || req.Name == "" || req.Version == "" {
+		return nil, nil, fmt.Errorf("request %s is missing org, name or version", path)
+	}
+	return body, req, nil
+}
+
+// sendOptions are the flags shared by recording and replaying a release.
+type sendOptions struct {
+	registryURL string
+	token       string
+	retry       registry.RetryPolicy
+	dryRun      bool
+}
+
+func (o *sendOptions) register(fs *flag.FlagSet) {
+	o.retry = registry.DefaultRetryPolicy
+	fs.StringVar(&o.registryURL, "registry-url", "", "Registry API base URL (required)")
+	fs.StringVar(&o.token, "token", "", "Bearer token (or set REGISTRY_API_TOKEN env var)")
+	fs.IntVar(&o.retry.MaxAttempts, "max-attempts", o.retry.MaxAttempts, "Total attempts on 429, 5xx and network errors (1 disables retries)")
+	fs.DurationVar(&o.retry.BaseDelay, "retry-base-delay", o.retry.BaseDelay, "Backoff before the first retry; doubles on each retry, with jitter")
+	fs.DurationVar(&o.retry.MaxDelay, "retry-max-delay", o.retry.MaxDelay, "Maximum backoff between retries, also caps Retry-After")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Print the endpoint, redacted headers and JSON body instead of sending them")
+}
+
+// resolveToken applies the flag > env var precedence. A token is optional for dry runs.
+func (o *sendOptions) resolveToken() error {
+	if o.token == "" {
+		o.token = os.Getenv("REGISTRY_API_TOKEN")
+	}
+	if o.token == "" && !o.dryRun {
+		return errors.New("bearer token required (use -token flag or REGISTRY_API_TOKEN env var)")
 	}
-	resp, err := client.Do(httpReq)
+	return nil
+}
+
+// send posts body to the registry, retrying transient failures with the same
+// idempotency key, and prints the outcome as JSON. With -dry-run it prints the
+// request instead.
+func (o *sendOptions) send(ctx context.Context, body []byte, idempotencyKey, version string) error {
+	endpoint, err := registry.EndpointURL(o.registryURL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: HTTP request failed: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	client := &registry.Client{
+		Endpoint:   endpoint,
+		Token:      o.token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Retry:      o.retry,
+		Logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "record-release: warning: "+format+"\n", args...)
+		},
+	}
+
+	if o.dryRun {
+		req, err := client.NewRequest(ctx, body, idempotencyKey)
+		if err != nil {
+			return err
+		}
+		return writeDryRun(os.Stdout, req, body)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := client.Post(ctx, body, idempotencyKey)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "record-release: error: reading response body: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Handle response codes
@@ -295,76 +336,40 @@ func main() {
 		fmt.Println(string(result))
 	default:
 		fmt.Fprintf(os.Stderr, "::error::Registry API record failed: HTTP %d\n", resp.StatusCode)
-		fmt.Fprintf(os.Stderr, "%s\n", string(respBody))
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "%s\n", string(resp.Body))
+		return fmt.Errorf("registry returned HTTP %d after %d attempt(s)", resp.StatusCode, resp.Attempts)
 	}
+	return nil
 }
 
-func transformAssets(manifest *pb.Manifest) map[string]*ReleaseAsset {
-	assets := make(map[string]*ReleaseAsset)
-	for platform, asset := range manifest.GetAssets() {
-		assets[platform] = &ReleaseAsset{
-			Platform:       platform,
-			Filename:       asset.GetFilename(),
-			MediaType:      asset.GetMediaType(),
-			SizeBytes:      asset.GetSizeBytes(),
-			Sha256:         asset.GetSha256(),
-			DownloadURL:    asset.GetHref(),
-			SignatureURL:   asset.GetSignatureHref(),
-			CertificateURL: asset.GetCertificateHref(),
-			SbomURL:        asset.GetSbomHref(),
-			Attestations:   transformAttestations(asset.GetAttestations()),
-		}
+// writeDryRun prints the request line, headers with credentials redacted, and
+// the exact body that would be sent.
+func writeDryRun(w io.Writer, req *http.Request, body []byte) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", req.Method, req.URL)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
 	}
-
-	return assets
-}
-
-func transformImages(manifest *pb.Manifest) map[string]*ReleaseImage {
-	images := make(map[string]*ReleaseImage)
-	for platform, image := range manifest.GetImages() {
-		images[platform] = &ReleaseImage{
-			Ref:          image.GetRef(),
-			Digest:       image.GetDigest(),
-			Platform:     platform,
-			Attestations: transformImageAttestations(image, manifest.GetImageAttestation()),
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(&sb, "%s: %s\n", name, redactHeader(name, value))
 		}
 	}
-
-	return images
+	sb.WriteString("\n")
+	sb.Write(body)
+	sb.WriteString("\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
 }
 
-// Asset-level attestations are the source of truth in the registry. The dist
-// exporter derives the manifest-level assetAttestation summary from them.
-func transformAttestations(in []*pb.AttestationDescriptor) []*ReleaseAttestation {
-	var out []*ReleaseAttestation
-	for _, att := range in {
-		if att.GetPredicateType() == "" || att.GetBundleHref() == "" {
-			continue
-		}
-		out = append(out, &ReleaseAttestation{
-			Type: att.GetPredicateType(),
-			URL:  att.GetBundleHref(),
-		})
+func redactHeader(name, value string) string {
+	if !strings.EqualFold(name, "Authorization") {
+		return value
 	}
-	return out
-}
-
-func transformImageAttestations(image *pb.Image, att *pb.AttestationDescriptor) []*ReleaseAttestation {
-	if image == nil || !image.GetIsIndex() {
-		return nil
-	}
-	if att == nil || att.GetPredicateType() == "" {
-		return nil
-	}
-	// The registry stores image attestations per image. Dist manifests keep a
-	// single imageAttestation summary, and the exporter recreates that summary
-	// from the per-image entries.
-	//
-	// Image attestations are discovered through OCI referrers, so bundleHref is
-	// intentionally empty in current release manifests.
-	return []*ReleaseAttestation{{
-		Type: att.GetPredicateType(),
-		URL:  att.GetBundleHref(),
-	}}
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " [REDACTED]"
+	}
+	return "[REDACTED]"
 }
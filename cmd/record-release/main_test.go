@@ -1,163 +1,68 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"reflect"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
-	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/internal/registry"
 )
 
-const (
-	inTotoStatement = "https://in-toto.io/Statement/v1"
-	slsaProvenance  = "https://slsa.dev/provenance/v1"
-	spdxDocument    = "https://spdx.dev/Document"
-)
-
-func TestTransformAssetsPreservesAssetAttestations(t *testing.T) {
-	sizeBytes := int64(123)
-	manifest := pb.Manifest_builder{
-		Assets: map[string]*pb.Asset{
-			"linux-amd64": pb.Asset_builder{
-				Filename:  strPtr("baton-example-v1.2.3-linux-amd64.tar.gz"),
-				MediaType: strPtr("application/gzip"),
-				SizeBytes: &sizeBytes,
-				Sha256:    strPtr("asset-sha"),
-				Href:      strPtr("https://dist.example.com/asset.tar.gz"),
-				Attestations: []*pb.AttestationDescriptor{
-					attestation(slsaProvenance, "https://dist.example.com/provenance.sigstore.json"),
-					attestation(spdxDocument, "https://dist.example.com/sbom.sigstore.json"),
-				},
-			}.Build(),
-		},
-	}.Build()
-
-	assets := transformAssets(manifest)
-	got := assets["linux-amd64"].Attestations
-	want := []*ReleaseAttestation{
-		{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
-		{Type: spdxDocument, URL: "https://dist.example.com/sbom.sigstore.json"},
-	}
-	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("attestations = %#v, want %#v", got, want)
+func TestWriteDryRunRedactsAuthorization(t *testing.T) {
+	client := &registry.Client{Endpoint: "https://registry.example.com" + registry.IngestPath, Token: "secret-token"}
+	body := []byte(`{"org":"example"}`)
+	req, err := client.NewRequest(context.Background(), body, "key-123")
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
 	}
-}
 
-func TestTransformAttestationsSkipsIncompleteAssetEntries(t *testing.T) {
-	got := transformAttestations([]*pb.AttestationDescriptor{
-		attestation(slsaProvenance, "https://dist.example.com/provenance.sigstore.json"),
-		attestation("", "https://dist.example.com/missing-predicate.sigstore.json"),
-		attestation(spdxDocument, ""),
-	})
-	want := []*ReleaseAttestation{
-		{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
+	var buf bytes.Buffer
+	if err := writeDryRun(&buf, req, body); err != nil {
+		t.Fatalf("writeDryRun: %v", err)
 	}
-	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("attestations = %#v, want %#v", got, want)
+	want := "POST https://registry.example.com/api/v1/ingest/release\n" +
+		"Authorization: Bearer [REDACTED]\n" +
+		"Content-Type: application/json\n" +
+		"Idempotency-Key: key-123\n" +
+		"\n" +
+		`{"org":"example"}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("dry run output:\n%s\nwant:\n%s", buf.String(), want)
 	}
-}
-
-func TestTransformImagesAppliesManifestImageAttestation(t *testing.T) {
-	isIndex := true
-	manifest := pb.Manifest_builder{
-		ImageAttestation: attestation(slsaProvenance, ""),
-		Images: map[string]*pb.Image{
-			"ecrPublic": pb.Image_builder{
-				Ref:     strPtr("public.ecr.aws/example/baton-example:v1.2.3"),
-				Digest:  strPtr("sha256:ecr"),
-				IsIndex: &isIndex,
-			}.Build(),
-		},
-	}.Build()
-
-	images := transformImages(manifest)
-	for platform, image := range images {
-		want := []*ReleaseAttestation{{Type: slsaProvenance}}
-		if !reflect.DeepEqual(image.Attestations, want) {
-			t.Fatalf("%s attestations = %#v, want %#v", platform, image.Attestations, want)
-		}
+	if strings.Contains(buf.String(), "secret-token") {
+		t.Fatal("dry run output leaks the token")
 	}
 }
 
-func TestTransformImagesSkipsAttestationForNonIndexImage(t *testing.T) {
-	isIndex := false
-	manifest := pb.Manifest_builder{
-		ImageAttestation: attestation(slsaProvenance, ""),
-		Images: map[string]*pb.Image{
-			"lambda-arm64": pb.Image_builder{
-				Ref:     strPtr("baton-example:1.2.3-arm64"),
-				Digest:  strPtr("sha256:lambda"),
-				IsIndex: &isIndex,
-			}.Build(),
-		},
-	}.Build()
-
-	images := transformImages(manifest)
-	if len(images["lambda-arm64"].Attestations) != 0 {
-		t.Fatalf("lambda attestations = %#v, want none", images["lambda-arm64"].Attestations)
+func TestReadRequestForReplay(t *testing.T) {
+	saved, err := json.Marshal(&registry.RecordReleaseRequest{Org: "example", Name: "baton-example", Version: "v1.2.3", CommitSha: "abc123"})
+	if err != nil {
+		t.Fatal(err)
 	}
-}
-
-func TestRecordReleaseRequestMarshalsAttestations(t *testing.T) {
-	req := &RecordReleaseRequest{
-		Org:     "example",
-		Name:    "baton-example",
-		Version: "v1.2.3",
-		Assets: map[string]*ReleaseAsset{
-			"linux-amd64": {
-				Platform: "linux-amd64",
-				Attestations: []*ReleaseAttestation{
-					{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
-				},
-			},
-		},
-		Images: map[string]*ReleaseImage{
-			"ecrPublic": {
-				Platform:     "ecrPublic",
-				Attestations: []*ReleaseAttestation{{Type: slsaProvenance}},
-			},
-		},
+	path := filepath.Join(t.TempDir(), "request.json")
+	if err := os.WriteFile(path, append(saved, '\n'), 0o600); err != nil {
+		t.Fatal(err)
 	}
 
-	body, err := json.Marshal(req)
+	body, req, err := readRequest(path)
 	if err != nil {
-		t.Fatalf("marshal request: %v", err)
+		t.Fatalf("readRequest: %v", err)
 	}
-
-	var got struct {
-		Assets map[string]struct {
-			Attestations []ReleaseAttestation `json:"attestations"`
-		} `json:"assets"`
-		Images map[string]struct {
-			Attestations []ReleaseAttestation `json:"attestations"`
-		} `json:"images"`
+	if !bytes.Equal(body, saved) {
+		t.Fatalf("body = %s, want %s", body, saved)
 	}
-	if err := json.Unmarshal(body, &got); err != nil {
-		t.Fatalf("unmarshal request: %v", err)
+	if req.Org != "example" || req.Name != "baton-example" || req.Version != "v1.2.3" || req.CommitSha != "abc123" {
+		t.Fatalf("request = %+v", req)
 	}
 
-	if len(got.Assets["linux-amd64"].Attestations) != 1 {
-		t.Fatalf("asset attestations = %#v, want one entry", got.Assets["linux-amd64"].Attestations)
-	}
-	if got.Assets["linux-amd64"].Attestations[0].URL == "" {
-		t.Fatal("asset attestation URL was not marshaled")
+	if err := os.WriteFile(path, []byte(`{"org":"example"}`), 0o600); err != nil {
+		t.Fatal(err)
 	}
-	if len(got.Images["ecrPublic"].Attestations) != 1 {
-		t.Fatalf("image attestations = %#v, want one entry", got.Images["ecrPublic"].Attestations)
+	if _, _, err := readRequest(path); err == nil {
+		t.Fatal("expected error for request without name and version")
 	}
-	if got.Images["ecrPublic"].Attestations[0].URL != "" {
-		t.Fatalf("image attestation URL = %q, want empty", got.Images["ecrPublic"].Attestations[0].URL)
-	}
-}
-
-func attestation(predicateType, bundleHref string) *pb.AttestationDescriptor {
-	return pb.AttestationDescriptor_builder{
-		AttestationType: strPtr(inTotoStatement),
-		PredicateType:   strPtr(predicateType),
-		BundleHref:      strPtr(bundleHref),
-	}.Build()
-}
-
-func strPtr(s string) *string {
-	return &s
 }
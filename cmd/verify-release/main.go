@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ConductorOne/github-workflows/internal/attestation"
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+	"github.com/ConductorOne/github-workflows/pkg/distclient"
+)
+
+// Check is the outcome of a single validation.
+type Check struct {
+	Subject string `json:"subject"`
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Report is the full pass/fail report for a release.
+type Report struct {
+	Org         string   `json:"org"`
+	Repo        string   `json:"repo"`
+	Version     string   `json:"version"`
+	ManifestURL string   `json:"manifestUrl"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Checks      []*Check `json:"checks"`
+}
+
+func (r *Report) add(subject, name string, err error) {
+	c := &Check{Subject: subject, Name: name, Passed: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+		r.Failed++
+	} else {
+		r.Passed++
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+func main() {
+	var (
+		baseURL string
+		org     string
+		repo    string
+		version string
+		format  string
+		timeout time.Duration
+
+		trustedRootPath string
+		identityRegexp  string
+		oidcIssuer      string
+	)
+	flag.StringVar(&baseURL, "base-url", distclient.DefaultBaseURL, "Base URL of the releases tree (manifest is read from {base-url}/{org}/{repo}/{version}/manifest.json)")
+	flag.StringVar(&org, "org", "", "GitHub organization (required)")
+	flag.StringVar(&repo, "repo", "", "Repository/connector name (required)")
+	flag.StringVar(&version, "version", "", "Release version tag, e.g. v0.1.98 (required)")
+	flag.StringVar(&format, "format", "text", "Report format: text or json")
+	flag.DurationVar(&timeout, "timeout", 10*time.Minute, "Overall timeout for all downloads")
+	flag.StringVar(&trustedRootPath, "trusted-root", "", "Sigstore trusted_root.json; when set, attestation bundles are verified offline instead of only resolved")
+	flag.StringVar(&identityRegexp, "certificate-identity-regexp", attestation.DefaultCertificateIdentityRegexp, "Regexp the attestation signing certificate identity must match")
+	flag.StringVar(&oidcIssuer, "certificate-oidc-issuer", attestation.DefaultCertificateOIDCIssuer, "OIDC issuer the attestation signing certificate must carry")
+	flag.Parse()
+
+	if org == "" || repo == "" || version == "" {
+		fmt.Fprintf(os.Stderr, "verify-release: error: org, repo, and version are required\n")
+		os.Exit(1)
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "verify-release: error: format must be text or json, got %q\n", format)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	v := &verifier{client: http.DefaultClient}
+	if trustedRootPath != "" {
+		trustedRoot, err := attestation.LoadTrustedRoot(trustedRootPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify-release: error: %v\n", err)
+			os.Exit(1)
+		}
+		v.attestations, err = attestation.NewVerifier(trustedRoot, identityRegexp, oidcIssuer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify-release: error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	report := v.verify(ctx, baseURL, org, repo, version)
+
+	var err error
+	if format == "json" {
+		err = writeJSON(os.Stdout, report)
+	} else {
+		err = writeText(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify-release: error: writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// verifier downloads a published release and checks it against its manifest.
+type verifier struct {
+	client *http.Client
+	// attestations verifies downloaded bundles; when nil bundle hrefs are only resolved.
+	attestations *attestation.Verifier
+}
+
+// verify fetches the manifest for org/repo/version under baseURL and validates
+// every asset, sidecar and image it references.
+func (v *verifier) verify(ctx context.Context, baseURL, org, repo, version string) *Report {
+	manifestURL := manifest.JoinURL(baseURL, fmt.Sprintf("%s/%s/%s/manifest.json", org, repo, version))
+	report := &Report{Org: org, Repo: repo, Version: version, ManifestURL: manifestURL}
+
+	m, err := v.fetchManifest(ctx, manifestURL)
+	report.add("manifest", "fetch", err)
+	if err != nil {
+		return report
+	}
+
+	report.add("manifest", "semver", expectEqual("semver", m.GetSemver(), version))
+	report.add("manifest", "signature", v.resolve(ctx, m.GetSignatureHref()))
+	report.add("manifest", "certificate", v.resolve(ctx, m.GetCertificateHref()))
+
+	assetKeys := manifest.SortedKeys(m.GetAssets())
+	if len(assetKeys) == 0 {
+		report.add("manifest", "assets", errors.New("manifest has no assets"))
+	}
+	for _, key := range assetKeys {
+		v.verifyAsset(ctx, report, key, m.GetAssets()[key])
+	}
+	for _, key := range manifest.SortedKeys(m.GetImages()) {
+		report.add("image "+key, "digest", verifyImage(m.GetImages()[key]))
+	}
+	return report
+}
+
+func (v *verifier) verifyAsset(ctx context.Context, report *Report, key string, asset *pb.Asset) {
+	subject := "asset " + key
+	report.add(subject, "download", v.download(ctx, asset))
+	if asset.GetSignatureHref() != "" {
+		report.add(subject, "signature", v.resolve(ctx, asset.GetSignatureHref()))
+	}
+	if asset.GetCertificateHref() != "" {
+		report.add(subject, "certificate", v.resolve(ctx, asset.GetCertificateHref()))
+	}
+	for _, att := range asset.GetAttestations() {
+		if att.GetBundleHref() == "" {
+			continue
+		}
+		name := "attestation " + att.GetPredicateType()
+		if v.attestations == nil {
+			report.add(subject, name, v.resolve(ctx, att.GetBundleHref()))
+			continue
+		}
+		report.add(subject, name, v.verifyAttestation(ctx, asset, att))
+	}
+}
+
+// verifyAttestation downloads the bundle for att and verifies it against asset.
+func (v *verifier) verifyAttestation(ctx context.Context, asset *pb.Asset, att *pb.AttestationDescriptor) error {
+	resp, err := v.get(ctx, http.MethodGet, att.GetBundleHref())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading bundle %s: %w", att.GetBundleHref(), err)
+	}
+	return v.attestations.VerifyBundleJSON(data, asset, att)
+}
+
+func (v *verifier) fetchManifest(ctx context.Context, manifestURL string) (*pb.Manifest, error) {
+	resp, err := v.get(ctx, http.MethodGet, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	m := &pb.Manifest{}
+	if err := manifest.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	return m, nil
+}
+
+// download streams the asset and compares its size and SHA-256 with the manifest.
+func (v *verifier) download(ctx context.Context, asset *pb.Asset) error {
+	resp, err := v.get(ctx, http.MethodGet, asset.GetHref())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, resp.Body)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", asset.GetHref(), err)
+	}
+	if size != asset.GetSizeBytes() {
+		return fmt.Errorf("size %d bytes, manifest says %d", size, asset.GetSizeBytes())
+	}
+	return expectEqual("sha256", hex.EncodeToString(h.Sum(nil)), strings.ToLower(asset.GetSha256()))
+}
+
+// resolve checks that href exists, falling back to GET for servers that reject HEAD.
+func (v *verifier) resolve(ctx context.Context, href string) error {
+	if href == "" {
+		return errors.New("href is empty")
+	}
+	resp, err := v.get(ctx, http.MethodHead, href)
+	if errors.Is(err, errMethodNotAllowed) {
+		resp, err = v.get(ctx, http.MethodGet, href)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+// get issues a request and returns the response only for 2xx status codes.
+func (v *verifier) get(ctx context.Context, method, href string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", href, err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, href, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusMethodNotAllowed {
+			return nil, fmt.Errorf("%s %s: %w", method, href, errMethodNotAllowed)
+		}
+		return nil, fmt.Errorf("%s %s: HTTP %d", method, href, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// verifyImage checks that the digest is well formed and that uri is ref without its tag, pinned to digest.
+func verifyImage(image *pb.Image) error {
+	if !manifest.IsImageDigest(image.GetDigest()) {
+		return fmt.Errorf("digest %q is not sha256:<64 hex>", image.GetDigest())
+	}
+	repo, _, ok := manifest.SplitImageRef(image.GetRef())
+	if !ok {
+		return fmt.Errorf("ref %q has no tag", image.GetRef())
+	}
+	return expectEqual("uri", image.GetUri(), repo+"@"+image.GetDigest())
+}
+
+func expectEqual(field, got, want string) error {
+	if got != want {
+		return fmt.Errorf("%s %q, want %q", field, got, want)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func writeText(w io.Writer, report *Report) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔍 Validating release: %s/%s %s\n", report.Org, report.Repo, report.Version)
+	fmt.Fprintf(&sb, "   Manifest URL: %s\n\n", report.ManifestURL)
+	for _, c := range report.Checks {
+		if c.Passed {
+			fmt.Fprintf(&sb, "✅ %s: %s\n", c.Subject, c.Name)
+		} else {
+			fmt.Fprintf(&sb, "❌ %s: %s: %s\n", c.Subject, c.Name, c.Detail)
+		}
+	}
+	fmt.Fprintf(&sb, "\nPassed: %d\nFailed: %d\n", report.Passed, report.Failed)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// newFixtureServer serves a release under /ConductorOne/baton-example/v0.1.2/.
+// files maps paths below that directory to their contents; the manifest is built
+// from assets so hrefs point back at the server.
+func newFixtureServer(t *testing.T, files map[string]string, build func(base string) *pb.Manifest) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	dir := "/ConductorOne/baton-example/v0.1.2/"
+	data, err := manifest.Marshal(build(srv.URL + dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["manifest.json"] = string(data)
+	mux.HandleFunc(dir, func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[strings.TrimPrefix(r.URL.Path, dir)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	})
+	return srv
+}
+
+func testAsset(base, filename, content string) *pb.Asset {
+	sum := sha256.Sum256([]byte(content))
+	size := int64(len(content))
+	return pb.Asset_builder{
+		Filename:        strPtr(filename),
+		SizeBytes:       &size,
+		Sha256:          strPtr(hex.EncodeToString(sum[:])),
+		Href:            strPtr(base + filename),
+		SignatureHref:   strPtr(base + filename + ".sig"),
+		CertificateHref: strPtr(base + filename + ".cert"),
+		Attestations: []*pb.AttestationDescriptor{
+			manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, base+filename+manifest.ProvenanceBundleSuffix),
+		},
+	}.Build()
+}
+
+func testManifest(base string, assets map[string]*pb.Asset, images map[string]*pb.Image) *pb.Manifest {
+	return pb.Manifest_builder{
+		Version:         strPtr(manifest.SchemaVersion),
+		Semver:          strPtr("v0.1.2"),
+		Assets:          assets,
+		Images:          images,
+		SignatureHref:   strPtr(base + "manifest.json.sig"),
+		CertificateHref: strPtr(base + "manifest.json.cert"),
+	}.Build()
+}
+
+func TestVerifyPassesForCompleteRelease(t *testing.T) {
+	files := map[string]string{
+		"a.tar.gz":                          "archive",
+		"a.tar.gz.sig":                      "sig",
+		"a.tar.gz.cert":                     "cert",
+		"a.tar.gz.provenance.sigstore.json": "{}",
+		"manifest.json.sig":                 "sig",
+		"manifest.json.cert":                "cert",
+	}
+	srv := newFixtureServer(t, files, func(base string) *pb.Manifest {
+		return testManifest(base,
+			map[string]*pb.Asset{"linux-amd64": testAsset(base, "a.tar.gz", "archive")},
+			map[string]*pb.Image{"ecrPublic": pb.Image_builder{
+				Ref:    strPtr("public.ecr.aws/conductorone/baton-example:0.1.2"),
+				Digest: strPtr(testDigest),
+				Uri:    strPtr("public.ecr.aws/conductorone/baton-example@" + testDigest),
+			}.Build()})
+	})
+
+	v := &verifier{client: srv.Client()}
+	report := v.verify(context.Background(), srv.URL, "ConductorOne", "baton-example", "v0.1.2")
+	if report.Failed != 0 {
+		var sb strings.Builder
+		_ = writeText(&sb, report)
+		t.Fatalf("expected all checks to pass:\n%s", sb.String())
+	}
+	// fetch, semver, manifest sig+cert, asset download+sig+cert+provenance, image digest
+	if report.Passed != 9 {
+		t.Fatalf("passed = %d, want 9", report.Passed)
+	}
+}
+
+func TestVerifyReportsCorruptAndMissingFiles(t *testing.T) {
+	files := map[string]string{
+		"a.tar.gz":          "tampered",
+		"a.tar.gz.sig":      "sig",
+		"manifest.json.sig": "sig",
+	}
+	srv := newFixtureServer(t, files, func(base string) *pb.Manifest {
+		return testManifest(base,
+			map[string]*pb.Asset{"linux-amd64": testAsset(base, "a.tar.gz", "original")},
+			map[string]*pb.Image{"ecrPublic": pb.Image_builder{
+				Ref:    strPtr("public.ecr.aws/conductorone/baton-example:0.1.2"),
+				Digest: strPtr(testDigest),
+				Uri:    strPtr("public.ecr.aws/conductorone/other@" + testDigest),
+			}.Build()})
+	})
+
+	v := &verifier{client: srv.Client()}
+	report := v.verify(context.Background(), srv.URL, "ConductorOne", "baton-example", "v0.1.2")
+
+	failed := map[string]bool{}
+	for _, c := range report.Checks {
+		if !c.Passed {
+			failed[c.Subject+": "+c.Name] = true
+		}
+	}
+	for _, want := range []string{
+		"manifest: certificate",
+		"asset linux-amd64: download",
+		"asset linux-amd64: certificate",
+		"asset linux-amd64: attestation " + manifest.PredicateTypeSLSAProvenanceV1,
+		"image ecrPublic: digest",
+	} {
+		if !failed[want] {
+			t.Errorf("expected failed check %q, got %v", want, failed)
+		}
+	}
+	if report.Failed != len(failed) || report.Failed != 5 {
+		t.Fatalf("failed = %d, want 5", report.Failed)
+	}
+}
+
+func TestVerifyMissingManifest(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	v := &verifier{client: srv.Client()}
+	report := v.verify(context.Background(), srv.URL, "ConductorOne", "baton-example", "v0.1.2")
+	if report.Failed != 1 || len(report.Checks) != 1 {
+		t.Fatalf("report = %+v, want a single failed fetch", report)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
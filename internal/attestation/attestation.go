@@ -0,0 +1,131 @@
+// Package attestation verifies the Sigstore bundles referenced by
+// AttestationDescriptor.bundle_href against the asset they describe.
+//
+// Verification is offline: the caller supplies the Sigstore trusted root
+// (Fulcio CAs, Rekor keys) instead of fetching it through TUF.
+package attestation
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const (
+	// DefaultCertificateIdentityRegexp matches certificates issued to the shared release workflow.
+	DefaultCertificateIdentityRegexp = `^https://github\.com/ConductorOne/github-workflows/\.github/workflows/release\.yaml@`
+	// DefaultCertificateOIDCIssuer is the GitHub Actions OIDC issuer.
+	DefaultCertificateOIDCIssuer = "https://token.actions.githubusercontent.com"
+)
+
+var (
+	// ErrSubjectMismatch is returned when no statement subject carries the asset's SHA-256.
+	ErrSubjectMismatch = errors.New("attestation subject does not match asset sha256")
+	// ErrPredicateMismatch is returned when the statement predicate type differs from the descriptor.
+	ErrPredicateMismatch = errors.New("attestation predicate type does not match descriptor")
+)
+
+// Verifier checks Sigstore attestation bundles against a trusted root and certificate identity.
+type Verifier struct {
+	verifier *verify.Verifier
+	identity verify.CertificateIdentity
+}
+
+// LoadTrustedRoot reads a Sigstore trusted_root.json file.
+func LoadTrustedRoot(path string) (root.TrustedMaterial, error) {
+	trustedRoot, err := root.NewTrustedRootFromPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading trusted root %s: %w", path, err)
+	}
+	return trustedRoot, nil
+}
+
+// NewVerifier returns a Verifier that accepts bundles signed by a certificate whose
+// SAN matches identityRegexp and whose OIDC issuer equals oidcIssuer, logged in a
+// transparency log from trusted.
+func NewVerifier(trusted root.TrustedMaterial, identityRegexp, oidcIssuer string) (*Verifier, error) {
+	identity, err := verify.NewShortCertificateIdentity(oidcIssuer, "", "", identityRegexp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate identity: %w", err)
+	}
+	v, err := verify.NewVerifier(trusted, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	if err != nil {
+		return nil, fmt.Errorf("creating verifier: %w", err)
+	}
+	return &Verifier{verifier: v, identity: identity}, nil
+}
+
+// VerifyBundleFile verifies the bundle at path for asset. See Verify.
+func (v *Verifier) VerifyBundleFile(path string, asset *pb.Asset, descriptor *pb.AttestationDescriptor) error {
+	b, err := bundle.LoadJSONFromPath(path)
+	if err != nil {
+		return fmt.Errorf("loading bundle %s: %w", path, err)
+	}
+	return v.Verify(b, asset, descriptor)
+}
+
+// VerifyBundleJSON verifies a bundle already read into memory. See Verify.
+func (v *Verifier) VerifyBundleJSON(data []byte, asset *pb.Asset, descriptor *pb.AttestationDescriptor) error {
+	b := &bundle.Bundle{}
+	if err := b.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("parsing bundle: %w", err)
+	}
+	return v.Verify(b, asset, descriptor)
+}
+
+// Verify checks that entity is a DSSE in-toto attestation whose subject digest is
+// asset's sha256 and whose predicate type is descriptor's, then verifies the
+// signature, certificate chain, identity and transparency log entry.
+func (v *Verifier) Verify(entity verify.SignedEntity, asset *pb.Asset, descriptor *pb.AttestationDescriptor) error {
+	digest, err := hex.DecodeString(asset.GetSha256())
+	if err != nil || len(digest) == 0 {
+		return fmt.Errorf("asset %s has invalid sha256 %q", asset.GetFilename(), asset.GetSha256())
+	}
+
+	// Check the statement first so that a bundle attached to the wrong artifact
+	// produces a specific error instead of a generic verification failure.
+	if err := checkStatement(entity, asset.GetSha256(), descriptor.GetPredicateType()); err != nil {
+		return err
+	}
+
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest), verify.WithCertificateIdentity(v.identity))
+	if _, err := v.verifier.Verify(entity, policy); err != nil {
+		return fmt.Errorf("verifying bundle for %s: %w", asset.GetFilename(), err)
+	}
+	return nil
+}
+
+func checkStatement(entity verify.SignedEntity, sha256Hex, predicateType string) error {
+	content, err := entity.SignatureContent()
+	if err != nil {
+		return fmt.Errorf("reading bundle signature: %w", err)
+	}
+	envelope := content.EnvelopeContent()
+	if envelope == nil {
+		return errors.New("bundle does not contain a DSSE envelope")
+	}
+	statement, err := envelope.Statement()
+	if err != nil {
+		return fmt.Errorf("reading in-toto statement: %w", err)
+	}
+
+	if statement.GetPredicateType() != predicateType {
+		return fmt.Errorf("%w: bundle has %q, descriptor has %q", ErrPredicateMismatch, statement.GetPredicateType(), predicateType)
+	}
+	var subjects []string
+	for _, subject := range statement.GetSubject() {
+		got := subject.GetDigest()["sha256"]
+		if strings.EqualFold(got, sha256Hex) {
+			return nil
+		}
+		subjects = append(subjects, fmt.Sprintf("%s sha256:%s", subject.GetName(), got))
+	}
+	return fmt.Errorf("%w: want sha256:%s, bundle subjects are [%s]", ErrSubjectMismatch, sha256Hex, strings.Join(subjects, ", "))
+}
@@ -0,0 +1,122 @@
+package attestation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const testIdentity = "https://github.com/ConductorOne/github-workflows/.github/workflows/release.yaml@refs/tags/v4.1.0"
+
+func statement(sha256Hex, predicateType string) []byte {
+	return fmt.Appendf(nil, `{
+  "_type": %q,
+  "subject": [{"name": "baton-example-v0.1.2-linux-amd64.tar.gz", "digest": {"sha256": %q}}],
+  "predicateType": %q,
+  "predicate": {}
+}`, manifest.AttestationTypeInTotoV1, sha256Hex, predicateType)
+}
+
+func testAsset(content string) *pb.Asset {
+	sum := sha256.Sum256([]byte(content))
+	return pb.Asset_builder{
+		Filename: strPtr("baton-example-v0.1.2-linux-amd64.tar.gz"),
+		Sha256:   strPtr(hex.EncodeToString(sum[:])),
+	}.Build()
+}
+
+func newTestVerifier(t *testing.T) (*ca.VirtualSigstore, *Verifier) {
+	t.Helper()
+	vs, err := ca.NewVirtualSigstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewVerifier(vs, DefaultCertificateIdentityRegexp, DefaultCertificateOIDCIssuer)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return vs, v
+}
+
+func TestVerifyAcceptsMatchingBundle(t *testing.T) {
+	vs, v := newTestVerifier(t)
+	asset := testAsset("archive")
+	entity, err := vs.Attest(testIdentity, DefaultCertificateOIDCIssuer, statement(asset.GetSha256(), manifest.PredicateTypeSLSAProvenanceV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(entity, asset, manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, "")); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestVerifyRejectsBundleForOtherArtifact(t *testing.T) {
+	vs, v := newTestVerifier(t)
+	other := testAsset("other archive")
+	entity, err := vs.Attest(testIdentity, DefaultCertificateOIDCIssuer, statement(other.GetSha256(), manifest.PredicateTypeSLSAProvenanceV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = v.Verify(entity, testAsset("archive"), manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, ""))
+	if !errors.Is(err, ErrSubjectMismatch) {
+		t.Fatalf("err = %v, want ErrSubjectMismatch", err)
+	}
+}
+
+func TestVerifyRejectsWrongPredicateType(t *testing.T) {
+	vs, v := newTestVerifier(t)
+	asset := testAsset("archive")
+	entity, err := vs.Attest(testIdentity, DefaultCertificateOIDCIssuer, statement(asset.GetSha256(), manifest.PredicateTypeSPDX))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = v.Verify(entity, asset, manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, ""))
+	if !errors.Is(err, ErrPredicateMismatch) {
+		t.Fatalf("err = %v, want ErrPredicateMismatch", err)
+	}
+}
+
+func TestVerifyRejectsUntrustedIdentity(t *testing.T) {
+	vs, v := newTestVerifier(t)
+	asset := testAsset("archive")
+	forked := "https://github.com/someone-else/github-workflows/.github/workflows/release.yaml@refs/heads/main"
+	entity, err := vs.Attest(forked, DefaultCertificateOIDCIssuer, statement(asset.GetSha256(), manifest.PredicateTypeSLSAProvenanceV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(entity, asset, manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, "")); err == nil {
+		t.Fatal("expected identity verification to fail")
+	}
+}
+
+func TestVerifyRejectsUntrustedRoot(t *testing.T) {
+	signer, err := ca.NewVirtualSigstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, v := newTestVerifier(t)
+	asset := testAsset("archive")
+	entity, err := signer.Attest(testIdentity, DefaultCertificateOIDCIssuer, statement(asset.GetSha256(), manifest.PredicateTypeSLSAProvenanceV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(entity, asset, manifest.NewAttestation(manifest.PredicateTypeSLSAProvenanceV1, "")); err == nil {
+		t.Fatal("expected verification against a different trusted root to fail")
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,179 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const (
+	// AttestationTypeInTotoV1 is the in-toto Statement v1 envelope type
+	AttestationTypeInTotoV1 = "https://in-toto.io/Statement/v1"
+	// PredicateTypeSLSAProvenanceV1 is the SLSA v1 provenance predicate type
+	PredicateTypeSLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
+	// PredicateTypeSPDX is the SPDX SBOM predicate type
+	PredicateTypeSPDX = "https://spdx.dev/Document"
+)
+
+const (
+	// ProvenanceBundleSuffix is appended to an artifact filename to name its provenance bundle.
+	ProvenanceBundleSuffix = ".provenance.sigstore.json"
+	// SBOMBundleSuffix is appended to an artifact filename to name its SBOM bundle.
+	SBOMBundleSuffix = ".sbom.sigstore.json"
+)
+
+// attestationBundles maps bundle suffixes to their predicate types, in the order
+// attestations are listed on an asset.
+var attestationBundles = []struct {
+	suffix        string
+	predicateType string
+}{
+	{ProvenanceBundleSuffix, PredicateTypeSLSAProvenanceV1},
+	{SBOMBundleSuffix, PredicateTypeSPDX},
+}
+
+// AssetOptions controls how NewAsset describes a file.
+type AssetOptions struct {
+	// MediaType is the MIME type recorded on the asset.
+	MediaType string
+	// SHA256 is the hex digest to record. When empty the file is hashed.
+	SHA256 string
+	// BaseURL is the URL prefix the file and its sidecars are published under.
+	BaseURL string
+	// AssumeSigned sets signature and certificate hrefs even if the .sig/.cert
+	// files are not in dir yet (signing happens after the manifest is generated).
+	AssumeSigned bool
+}
+
+// NewAsset builds an Asset for dir/filename, including any attestation bundles found next to it.
+func NewAsset(dir, filename string, opts AssetOptions) (*pb.Asset, error) {
+	path := filepath.Join(dir, filename)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("getting file info: %w", err)
+	}
+	size := info.Size()
+
+	hash := opts.SHA256
+	if hash == "" {
+		hash, err = SHA256File(path)
+		if err != nil {
+			return nil, fmt.Errorf("calculating hash: %w", err)
+		}
+	}
+
+	href := JoinURL(opts.BaseURL, filename)
+	builder := pb.Asset_builder{
+		Filename:  &filename,
+		MediaType: &opts.MediaType,
+		SizeBytes: &size,
+		Sha256:    &hash,
+		Href:      &href,
+	}
+	if opts.AssumeSigned || fileExists(path+".sig") {
+		builder.SignatureHref = stringPtr(href + ".sig")
+	}
+	if opts.AssumeSigned || fileExists(path+".cert") {
+		builder.CertificateHref = stringPtr(href + ".cert")
+	}
+	builder.Attestations = DetectAttestations(dir, filename, opts.BaseURL)
+
+	return builder.Build(), nil
+}
+
+// DetectAttestations returns a descriptor for each Sigstore attestation bundle
+// stored next to filename in dir, with bundle hrefs under baseURL.
+func DetectAttestations(dir, filename, baseURL string) []*pb.AttestationDescriptor {
+	var attestations []*pb.AttestationDescriptor
+	for _, b := range attestationBundles {
+		if !fileExists(filepath.Join(dir, filename+b.suffix)) {
+			continue
+		}
+		attestations = append(attestations, NewAttestation(b.predicateType, JoinURL(baseURL, filename+b.suffix)))
+	}
+	return attestations
+}
+
+// NewAttestation returns an in-toto AttestationDescriptor for predicateType.
+// bundleHref may be empty for OCI-native attestations discovered via referrers.
+func NewAttestation(predicateType, bundleHref string) *pb.AttestationDescriptor {
+	attestationType := AttestationTypeInTotoV1
+	builder := pb.AttestationDescriptor_builder{
+		AttestationType: &attestationType,
+		PredicateType:   &predicateType,
+	}
+	if bundleHref != "" {
+		builder.BundleHref = &bundleHref
+	}
+	return builder.Build()
+}
+
+// SummarizeAssetAttestations returns one descriptor per predicate type found
+// across the assets' attestations, sorted by predicate type. Bundle hrefs are
+// omitted because each asset has its own bundles.
+func SummarizeAssetAttestations(assets map[string]*pb.Asset) []*pb.AttestationDescriptor {
+	byType := map[string]*pb.AttestationDescriptor{}
+	for _, key := range SortedKeys(assets) {
+		for _, att := range assets[key].GetAttestations() {
+			if _, ok := byType[att.GetPredicateType()]; ok || att.GetPredicateType() == "" {
+				continue
+			}
+			builder := pb.AttestationDescriptor_builder{
+				AttestationType: stringPtr(att.GetAttestationType()),
+				PredicateType:   stringPtr(att.GetPredicateType()),
+			}
+			byType[att.GetPredicateType()] = builder.Build()
+		}
+	}
+	summary := make([]*pb.AttestationDescriptor, 0, len(byType))
+	for _, predicateType := range SortedKeys(byType) {
+		summary = append(summary, byType[predicateType])
+	}
+	return summary
+}
+
+// CheckAttestationSets reports, in key order, each attested asset that is
+// missing a predicate type carried by another asset. Assets without any
+// attestations (such as checksums) are not compared.
+func CheckAttestationSets(assets map[string]*pb.Asset) []string {
+	summary := SummarizeAssetAttestations(assets)
+	var problems []string
+	for _, key := range SortedKeys(assets) {
+		attestations := assets[key].GetAttestations()
+		if len(attestations) == 0 {
+			continue
+		}
+		have := make(map[string]bool, len(attestations))
+		for _, att := range attestations {
+			have[att.GetPredicateType()] = true
+		}
+		var missing []string
+		for _, att := range summary {
+			if !have[att.GetPredicateType()] {
+				missing = append(missing, att.GetPredicateType())
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: has no %s attestation that other assets carry", key, strings.Join(missing, ", ")))
+		}
+	}
+	return problems
+}
+
+// JoinURL joins a base URL and a file name with exactly one slash.
+func JoinURL(baseURL, name string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), name)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// stringPtr returns a pointer to the given string value.
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,222 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// ErrNoGoBinary is returned when an archive contains no executable with Go build info.
+var ErrNoGoBinary = errors.New("archive contains no Go binary")
+
+// executableMagics are the leading bytes of ELF, Mach-O (32/64-bit, both byte
+// orders) and PE files. Other archive entries are skipped without being read.
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce}, {0xce, 0xfa, 0xed, 0xfe},
+	{0xfe, 0xed, 0xfa, 0xcf}, {0xcf, 0xfa, 0xed, 0xfe},
+	[]byte("MZ"),
+}
+
+// IsArchive reports whether filename is a .zip, .tar.gz or .tgz archive.
+func IsArchive(filename string) bool {
+	name := strings.ToLower(filename)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// ReadArchiveBuildInfo returns the build info of the first Go binary in the
+// .zip, .tar.gz or .tgz archive at path, and the binary's name inside the archive.
+func ReadArchiveBuildInfo(path string) (*debug.BuildInfo, string, error) {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return readZipBuildInfo(path)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return readTarGzBuildInfo(path)
+	default:
+		return nil, "", fmt.Errorf("%s: unsupported archive format", path)
+	}
+}
+
+func readZipBuildInfo(path string) (*debug.BuildInfo, string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s in %s: %w", f.Name, path, err)
+		}
+		info, err := readEntryBuildInfo(rc)
+		rc.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s in %s: %w", f.Name, path, err)
+		}
+		if info != nil {
+			return info, f.Name, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%s: %w", path, ErrNoGoBinary)
+}
+
+func readTarGzBuildInfo(path string) (*debug.BuildInfo, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, "", fmt.Errorf("%s: %w", path, ErrNoGoBinary)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s: %w", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		info, err := readEntryBuildInfo(tr)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s in %s: %w", hdr.Name, path, err)
+		}
+		if info != nil {
+			return info, hdr.Name, nil
+		}
+	}
+}
+
+// readEntryBuildInfo returns the build info of an archive entry, or nil if the
+// entry is not an executable or not built by Go.
+func readEntryBuildInfo(r io.Reader) (*debug.BuildInfo, error) {
+	// debug/buildinfo needs random access, so executables are buffered in memory.
+	head := make([]byte, 4)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	if !isExecutable(head) {
+		return nil, nil
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	info, err := buildinfo.Read(bytes.NewReader(append(head, rest...)))
+	if err != nil {
+		// Not a Go binary (or stripped of build info); keep looking.
+		return nil, nil
+	}
+	return info, nil
+}
+
+func isExecutable(head []byte) bool {
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBuildInfo records info on asset: the Go version, main module, VCS
+// revision, build settings and dependencies.
+func SetBuildInfo(asset *pb.Asset, info *debug.BuildInfo) {
+	asset.SetGoVersion(info.GoVersion)
+	asset.SetMainModule(goModule(&info.Main))
+
+	settings := make(map[string]string, len(info.Settings))
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	asset.SetBuildSettings(settings)
+	if rev, ok := settings["vcs.revision"]; ok {
+		asset.SetVcsRevision(rev)
+	} else {
+		asset.ClearVcsRevision()
+	}
+
+	deps := make([]*pb.GoModule, 0, len(info.Deps))
+	for _, dep := range info.Deps {
+		deps = append(deps, goModule(dep))
+	}
+	asset.SetDependencies(deps)
+}
+
+func goModule(m *debug.Module) *pb.GoModule {
+	b := pb.GoModule_builder{
+		Path:    stringPtr(m.Path),
+		Version: stringPtr(m.Version),
+	}
+	if m.Sum != "" {
+		b.Sum = stringPtr(m.Sum)
+	}
+	if m.Replace != nil {
+		b.Replace = goModule(m.Replace)
+	}
+	return b.Build()
+}
+
+// VerifyBuildRevision checks that the binary described by asset was built
+// from commitSha in a clean tree: its vcs.revision must equal commitSha and
+// vcs.modified must be "false".
+func VerifyBuildRevision(asset *pb.Asset, commitSha string) error {
+	if asset.GetGoVersion() == "" {
+		return fmt.Errorf("%s has no build info recorded", asset.GetFilename())
+	}
+	revision := asset.GetVcsRevision()
+	if revision == "" {
+		return fmt.Errorf("%s was built without VCS stamping (no vcs.revision)", asset.GetFilename())
+	}
+	if !strings.EqualFold(revision, commitSha) {
+		return fmt.Errorf("%s was built from %s, release commit is %s", asset.GetFilename(), revision, commitSha)
+	}
+	switch modified := asset.GetBuildSettings()["vcs.modified"]; modified {
+	case "false":
+		return nil
+	case "true":
+		return fmt.Errorf("%s was built from a modified working tree (vcs.modified=true)", asset.GetFilename())
+	default:
+		return fmt.Errorf("%s has no vcs.modified build setting", asset.GetFilename())
+	}
+}
+
+// VerifyManifestRevisions runs VerifyBuildRevision on every archive asset in
+// m, in key order, and returns one problem per failing asset.
+func VerifyManifestRevisions(m *pb.Manifest, commitSha string) []string {
+	var problems []string
+	for _, key := range SortedKeys(m.GetAssets()) {
+		asset := m.GetAssets()[key]
+		if !IsArchive(asset.GetFilename()) {
+			continue
+		}
+		if err := VerifyBuildRevision(asset, commitSha); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	return problems
+}
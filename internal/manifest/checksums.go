@@ -0,0 +1,108 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumsPattern matches the goreleaser checksums file in a dist directory.
+const ChecksumsPattern = "*checksums.txt"
+
+// FindChecksumsFiles returns the sorted base names of the goreleaser checksums files in dir.
+// A clean dist directory holds exactly one; callers decide how to treat leftovers.
+func FindChecksumsFiles(dir string) ([]string, error) {
+	matches, err := Glob(dir, ChecksumsPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for checksums file: %w", err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("checksums file not found in %s (expected pattern: %s)", dir, ChecksumsPattern)
+	}
+	return matches, nil
+}
+
+// ReadChecksumsFile parses the checksums file at path and returns a map of filename -> SHA256 hash.
+func ReadChecksumsFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checksums file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	checksums, err := ParseChecksums(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums file %s: %w", path, err)
+	}
+	return checksums, nil
+}
+
+// ParseChecksums parses goreleaser checksums output and returns a map of filename -> SHA256 hash.
+// The format is: <sha256>  <filename> or <sha256>  *<filename>
+func ParseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) >= 2 {
+			checksums[strings.TrimPrefix(parts[1], "*")] = parts[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// ChecksumProblem describes a disagreement between a checksums file and the files on disk.
+type ChecksumProblem struct {
+	Filename string
+	// Expected is the hash recorded in the checksums file, empty if the file is not listed.
+	Expected string
+	// Actual is the hash of the file on disk, empty if the file is missing.
+	Actual string
+}
+
+func (p ChecksumProblem) String() string {
+	switch {
+	case p.Actual == "":
+		return fmt.Sprintf("%s is listed in checksums file but missing from disk (expected %s)", p.Filename, p.Expected)
+	case p.Expected == "":
+		return fmt.Sprintf("%s is not listed in checksums file (actual %s)", p.Filename, p.Actual)
+	default:
+		return fmt.Sprintf("%s checksum mismatch\n  - checksums file: %s\n  + actual:         %s", p.Filename, p.Expected, p.Actual)
+	}
+}
+
+// VerifyChecksums hashes each of filenames in dir and compares it with its entry in checksums,
+// reporting filenames that have no entry. It also reports every checksums entry without a
+// matching file in dir. Problems are returned sorted by filename; err is only set when a file
+// cannot be read.
+func VerifyChecksums(dir string, checksums map[string]string, filenames []string) ([]ChecksumProblem, error) {
+	var problems []ChecksumProblem
+	for _, filename := range filenames {
+		actual, err := SHA256File(filepath.Join(dir, filename))
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", filename, err)
+		}
+		expected := checksums[filename]
+		if !strings.EqualFold(expected, actual) {
+			problems = append(problems, ChecksumProblem{Filename: filename, Expected: expected, Actual: actual})
+		}
+	}
+	for filename, expected := range checksums {
+		if !fileExists(filepath.Join(dir, filename)) {
+			problems = append(problems, ChecksumProblem{Filename: filename, Expected: expected})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Filename < problems[j].Filename })
+	return problems, nil
+}
@@ -0,0 +1,146 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Image rule inputs name the digest file a rule reads.
+const (
+	// ImageInputImages is the digest file of the docker images build.
+	ImageInputImages = "images"
+	// ImageInputLambda is the digest file of the Lambda images build.
+	ImageInputLambda = "lambda"
+)
+
+// ImageRule maps the images pushed to one registry to a key in Manifest.images.
+type ImageRule struct {
+	// Key is the identifier used in Manifest.images (e.g., "ecrPublic").
+	Key string `json:"key"`
+	// Prefix is matched against the start of the image ref (e.g.,
+	// "public.ecr.aws/conductorone/"). An empty prefix matches every registry.
+	Prefix string `json:"prefix"`
+	// Tag is a path.Match glob for the ref's tag, in which "{version}" is
+	// replaced by the release version without its "v" (e.g., "{version}-arm64").
+	Tag string `json:"tag"`
+	// Index is true when the ref is a multi-arch index rather than a single
+	// platform manifest.
+	Index bool `json:"index"`
+	// Input is the digest file the rule reads: "images" (default) or "lambda".
+	Input string `json:"input,omitempty"`
+	// Optional rules do not fail extraction when no ref matches.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ImageRulesConfig is the on-disk format of an image rules file.
+type ImageRulesConfig struct {
+	Images []ImageRule `json:"images"`
+}
+
+// DefaultImageRules are the images published by the release workflow: the
+// multi-arch index on ECR Public and the arm64 Lambda image on private ECR.
+var DefaultImageRules = []ImageRule{
+	{Key: "ecrPublic", Prefix: "public.ecr.aws/conductorone/", Tag: "{version}", Index: true, Input: ImageInputImages},
+	{Key: "lambda-arm64", Tag: "{version}-arm64", Input: ImageInputLambda},
+}
+
+// LoadImageRules reads an ImageRulesConfig JSON file.
+func LoadImageRules(path string) ([]ImageRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading image rules: %w", err)
+	}
+	defer f.Close()
+
+	var cfg ImageRulesConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing image rules %s: %w", path, err)
+	}
+	for i, r := range cfg.Images {
+		r, err := normalizeImageRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("image rules %s: entry %d: %w", path, i, err)
+		}
+		cfg.Images[i] = r
+	}
+	return cfg.Images, nil
+}
+
+// ParseImageRule parses a "key=prefix,tag,index|single" flag value. Rules
+// parsed from flags read the "images" digest file.
+func ParseImageRule(s string) (ImageRule, error) {
+	key, rest, ok := strings.Cut(s, "=")
+	parts := strings.Split(rest, ",")
+	if !ok || len(parts) != 3 {
+		return ImageRule{}, fmt.Errorf("invalid image rule %q (expected key=prefix,tag,index|single)", s)
+	}
+	r := ImageRule{Key: key, Prefix: parts[0], Tag: parts[1]}
+	switch strings.TrimSpace(parts[2]) {
+	case "index":
+		r.Index = true
+	case "single":
+	default:
+		return ImageRule{}, fmt.Errorf("invalid image rule %q: kind must be index or single", s)
+	}
+	return normalizeImageRule(r)
+}
+
+// MergeImageRules returns base with each override replacing the rule with the
+// same key, or appended when the key is new.
+func MergeImageRules(base []ImageRule, overrides ...ImageRule) []ImageRule {
+	merged := append([]ImageRule(nil), base...)
+	for _, o := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == o.Key {
+				merged[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// MatchTag reports whether ref belongs to the rule's registry and carries a
+// tag matching the rule for version, and returns that tag.
+func (r ImageRule) MatchTag(ref, version string) (string, bool) {
+	repo, tag, ok := SplitImageRef(ref)
+	if !ok || !strings.HasPrefix(repo, r.Prefix) {
+		return "", false
+	}
+	matched, err := path.Match(strings.ReplaceAll(r.Tag, "{version}", version), tag)
+	if err != nil || !matched {
+		return "", false
+	}
+	return tag, true
+}
+
+func normalizeImageRule(r ImageRule) (ImageRule, error) {
+	r.Key = strings.TrimSpace(r.Key)
+	r.Prefix = strings.TrimSpace(r.Prefix)
+	r.Tag = strings.TrimSpace(r.Tag)
+	r.Input = strings.TrimSpace(r.Input)
+	if r.Key == "" || r.Tag == "" {
+		return r, fmt.Errorf("image rule requires both key and tag (got key=%q tag=%q)", r.Key, r.Tag)
+	}
+	if _, err := path.Match(r.Tag, ""); err != nil {
+		return r, fmt.Errorf("image rule tag %q for %s: %w", r.Tag, r.Key, err)
+	}
+	switch r.Input {
+	case "":
+		r.Input = ImageInputImages
+	case ImageInputImages, ImageInputLambda:
+	default:
+		return r, fmt.Errorf("image rule %s: input must be %s or %s, got %q", r.Key, ImageInputImages, ImageInputLambda, r.Input)
+	}
+	return r, nil
+}
@@ -0,0 +1,260 @@
+package manifest
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+var (
+	sha256HexRE   = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	imageDigestRE = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+)
+
+// KnownPredicateTypes are the attestation predicate types the release workflow produces.
+var KnownPredicateTypes = []string{PredicateTypeSLSAProvenanceV1, PredicateTypeSPDX}
+
+// IsImageDigest reports whether digest has the sha256:<64 hex> form of an
+// image manifest digest.
+func IsImageDigest(digest string) bool {
+	return imageDigestRE.MatchString(digest)
+}
+
+// LintError is a single invariant violated by a document. Field is a dotted path
+// to the offending value, e.g. "assets.linux-amd64.sha256".
+type LintError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e LintError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+type linter struct {
+	prefix string
+	errs   []LintError
+}
+
+func (l *linter) errorf(field, format string, args ...any) {
+	l.errs = append(l.errs, LintError{Field: l.prefix + field, Message: fmt.Sprintf(format, args...)})
+}
+
+// LintManifest checks the invariants every published Manifest must satisfy and
+// returns one LintError per violation, or nil when m is valid.
+func LintManifest(m *pb.Manifest) []LintError {
+	l := &linter{}
+	l.manifest(m)
+	return l.errs
+}
+
+// LintStable checks stable and its embedded manifest.
+func LintStable(stable *pb.Stable) []LintError {
+	l := &linter{}
+	if stable.GetVersion() == "" {
+		l.errorf("version", "is empty")
+	}
+	if !stable.HasUpdatedAt() {
+		l.errorf("updated_at", "is not set")
+	}
+	if !stable.HasManifest() {
+		l.errorf("manifest", "is not set")
+		return l.errs
+	}
+	l.prefix = "manifest."
+	l.manifest(stable.GetManifest())
+	return l.errs
+}
+
+// LintManifestPath checks that m belongs at p, which must end in
+// {org}/{repo}/{tag}/manifest.json.
+func LintManifestPath(m *pb.Manifest, p string) []LintError {
+	l := &linter{}
+	segments := strings.Split(path.Clean(strings.ReplaceAll(p, `\`, "/")), "/")
+	if len(segments) < 4 || segments[len(segments)-1] != "manifest.json" {
+		l.errorf("", "path %q does not match {org}/{repo}/{tag}/manifest.json", p)
+		return l.errs
+	}
+	l.release(m, segments[len(segments)-4], segments[len(segments)-3])
+	if tag := segments[len(segments)-2]; m.GetSemver() != tag {
+		l.errorf("semver", "%q does not match path tag %q", m.GetSemver(), tag)
+	}
+	return l.errs
+}
+
+// LintStablePath checks that stable belongs at p, which must end in
+// {org}/{repo}/stable.json.
+func LintStablePath(stable *pb.Stable, p string) []LintError {
+	l := &linter{prefix: "manifest."}
+	segments := strings.Split(path.Clean(strings.ReplaceAll(p, `\`, "/")), "/")
+	if len(segments) < 3 || segments[len(segments)-1] != "stable.json" {
+		l.prefix = ""
+		l.errorf("", "path %q does not match {org}/{repo}/stable.json", p)
+		return l.errs
+	}
+	l.release(stable.GetManifest(), segments[len(segments)-3], segments[len(segments)-2])
+	return l.errs
+}
+
+// release compares the manifest's org and name with the path segments. GitHub
+// owners and repositories are case-insensitive.
+func (l *linter) release(m *pb.Manifest, org, repo string) {
+	if !strings.EqualFold(m.GetOrg(), org) {
+		l.errorf("org", "%q does not match path org %q", m.GetOrg(), org)
+	}
+	if !strings.EqualFold(m.GetName(), repo) {
+		l.errorf("name", "%q does not match path repo %q", m.GetName(), repo)
+	}
+}
+
+func (l *linter) manifest(m *pb.Manifest) {
+	if m.GetVersion() == "" {
+		l.errorf("version", "is empty")
+	}
+	if m.GetName() == "" {
+		l.errorf("name", "is empty")
+	}
+	if m.GetOrg() == "" {
+		l.errorf("org", "is empty")
+	}
+	if !semver.IsValid(m.GetSemver()) {
+		l.errorf("semver", "%q is not a valid semantic version", m.GetSemver())
+	}
+	l.optionalHTTPS("signature_href", m.GetSignatureHref())
+	l.optionalHTTPS("certificate_href", m.GetCertificateHref())
+	if m.HasAssetAttestation() {
+		l.attestation("asset_attestation", m.GetAssetAttestation())
+	}
+	for i, att := range m.GetAssetAttestations() {
+		l.attestation(fmt.Sprintf("asset_attestations[%d]", i), att)
+	}
+	if m.HasImageAttestation() {
+		l.attestation("image_attestation", m.GetImageAttestation())
+	}
+
+	if len(m.GetAssets()) == 0 {
+		l.errorf("assets", "is empty")
+	}
+	for _, key := range SortedKeys(m.GetAssets()) {
+		l.asset("assets."+key+".", m.GetAssets()[key])
+	}
+	for _, key := range SortedKeys(m.GetImages()) {
+		image := m.GetImages()[key]
+		l.image("images."+key+".", image)
+		if image.GetIsIndex() && !m.HasImageAttestation() {
+			l.errorf("image_attestation", "is not set but image %s is an index", key)
+		}
+	}
+}
+
+func (l *linter) asset(field string, asset *pb.Asset) {
+	if asset.GetFilename() == "" {
+		l.errorf(field+"filename", "is empty")
+	}
+	if !sha256HexRE.MatchString(asset.GetSha256()) {
+		l.errorf(field+"sha256", "%q is not 64 lowercase hex characters", asset.GetSha256())
+	}
+	if asset.GetSizeBytes() <= 0 {
+		l.errorf(field+"size_bytes", "is %d, want > 0", asset.GetSizeBytes())
+	}
+	if err := checkHTTPS(asset.GetHref()); err != nil {
+		l.errorf(field+"href", "%v", err)
+	}
+	l.optionalHTTPS(field+"signature_href", asset.GetSignatureHref())
+	l.optionalHTTPS(field+"certificate_href", asset.GetCertificateHref())
+	for i, att := range asset.GetAttestations() {
+		l.attestation(fmt.Sprintf("%sattestations[%d]", field, i), att)
+		l.optionalHTTPS(fmt.Sprintf("%sattestations[%d].bundle_href", field, i), att.GetBundleHref())
+	}
+}
+
+func (l *linter) attestation(field string, att *pb.AttestationDescriptor) {
+	if att.GetAttestationType() != AttestationTypeInTotoV1 {
+		l.errorf(field+".attestation_type", "%q is not %q", att.GetAttestationType(), AttestationTypeInTotoV1)
+	}
+	for _, known := range KnownPredicateTypes {
+		if att.GetPredicateType() == known {
+			return
+		}
+	}
+	l.errorf(field+".predicate_type", "%q is not a known predicate type", att.GetPredicateType())
+}
+
+func (l *linter) image(field string, image *pb.Image) {
+	if !imageDigestRE.MatchString(image.GetDigest()) {
+		l.errorf(field+"digest", "%q is not sha256:<64 hex>", image.GetDigest())
+	}
+	repo, tag, ok := SplitImageRef(image.GetRef())
+	if !ok {
+		l.errorf(field+"ref", "%q has no tag", image.GetRef())
+		return
+	}
+	if image.GetTag() != "" && image.GetTag() != tag {
+		l.errorf(field+"tag", "%q does not match ref tag %q", image.GetTag(), tag)
+	}
+	if want := repo + "@" + image.GetDigest(); image.GetUri() != want {
+		l.errorf(field+"uri", "%q, want %q", image.GetUri(), want)
+	}
+	if len(image.GetPlatforms()) > 0 && !image.GetIsIndex() {
+		l.errorf(field+"platforms", "is set but the image is not an index")
+	}
+	for i, p := range image.GetPlatforms() {
+		pfield := fmt.Sprintf("%splatforms[%d].", field, i)
+		if p.GetOs() == "" || p.GetArchitecture() == "" {
+			l.errorf(pfield+"architecture", "os and architecture are required (got %q/%q)", p.GetOs(), p.GetArchitecture())
+		}
+		if !imageDigestRE.MatchString(p.GetDigest()) {
+			l.errorf(pfield+"digest", "%q is not sha256:<64 hex>", p.GetDigest())
+		}
+	}
+}
+
+func (l *linter) optionalHTTPS(field, href string) {
+	if href == "" {
+		return
+	}
+	if err := checkHTTPS(href); err != nil {
+		l.errorf(field, "%v", err)
+	}
+}
+
+func checkHTTPS(href string) error {
+	if href == "" {
+		return fmt.Errorf("is empty")
+	}
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an https URL", href)
+	}
+	return nil
+}
+
+// SplitImageRef splits an image reference into its repository and tag. ok is
+// false when ref has no tag; a registry port is not mistaken for one.
+func SplitImageRef(ref string) (repo, tag string, ok bool) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref, "", false
+	}
+	return ref[:i], ref[i+1:], true
+}
+
+// SortedKeys returns the keys of m in sorted order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
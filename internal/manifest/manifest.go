@@ -0,0 +1,108 @@
+// Package manifest builds, reads and writes the release metadata documents
+// described by proto/artifacts/v1 (Manifest, Stable and Catalog).
+//
+// It is shared by the commands in cmd/ so that every producer and consumer
+// agrees on attestation conventions, hashing and JSON encoding.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const (
+	// SchemaVersion is the Manifest schema version written by this package.
+	SchemaVersion = "2"
+	// StableSchemaVersion is the Stable schema version written by this package.
+	StableSchemaVersion = "1"
+	// CatalogSchemaVersion is the Catalog schema version written by this package.
+	CatalogSchemaVersion = "1"
+)
+
+// MarshalOptions are the protojson options used for every document we publish.
+var MarshalOptions = protojson.MarshalOptions{
+	Multiline:       true,
+	Indent:          "  ",
+	EmitUnpopulated: true,
+}
+
+// UnmarshalOptions are the protojson options used to read documents.
+// Unknown fields are discarded so older tools can read manifests written by newer ones.
+var UnmarshalOptions = protojson.UnmarshalOptions{
+	DiscardUnknown: true,
+}
+
+// Marshal encodes m using MarshalOptions and rewrites the result with
+// Canonicalize, so the same message always produces the same bytes.
+func Marshal(m proto.Message) ([]byte, error) {
+	data, err := MarshalOptions.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+// Canonicalize re-encodes a JSON document with object keys sorted, two-space
+// indentation and no HTML escaping. protojson deliberately varies its
+// whitespace between runs, which would change the bytes (and signatures) of
+// otherwise identical documents. Number and string values are kept verbatim.
+func Canonicalize(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("canonicalizing JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("canonicalizing JSON: trailing data after document")
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("canonicalizing JSON: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Unmarshal decodes data into m using UnmarshalOptions.
+func Unmarshal(data []byte, m proto.Message) error {
+	return UnmarshalOptions.Unmarshal(data, m)
+}
+
+// ReadManifest reads and decodes a Manifest JSON file.
+func ReadManifest(path string) (*pb.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	m := &pb.Manifest{}
+	if err := Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ReadStable reads and decodes a Stable JSON file.
+func ReadStable(path string) (*pb.Stable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading stable: %w", err)
+	}
+	s := &pb.Stable{}
+	if err := Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing stable %s: %w", path, err)
+	}
+	return s, nil
+}
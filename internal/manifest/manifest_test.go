@@ -0,0 +1,637 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+func TestParseChecksums(t *testing.T) {
+	got, err := ParseChecksums(strings.NewReader(`
+aaa111  baton-example-v0.1.2-linux-amd64.tar.gz
+bbb222  *baton-example-v0.1.2-darwin-arm64.zip
+
+`))
+	if err != nil {
+		t.Fatalf("ParseChecksums: %v", err)
+	}
+	if got["baton-example-v0.1.2-linux-amd64.tar.gz"] != "aaa111" {
+		t.Fatalf("linux hash = %q", got["baton-example-v0.1.2-linux-amd64.tar.gz"])
+	}
+	if got["baton-example-v0.1.2-darwin-arm64.zip"] != "bbb222" {
+		t.Fatalf("darwin hash = %q (binary-mode prefix not stripped)", got["baton-example-v0.1.2-darwin-arm64.zip"])
+	}
+}
+
+func TestNewAssetDetectsSidecars(t *testing.T) {
+	dir := t.TempDir()
+	filename := "baton-example-v0.1.2-windows-amd64.zip"
+	writeFile(t, dir, filename, "archive")
+	writeFile(t, dir, filename+".sig", "sig")
+	writeFile(t, dir, filename+ProvenanceBundleSuffix, "{}")
+	writeFile(t, dir, filename+SBOMBundleSuffix, "{}")
+
+	asset, err := NewAsset(dir, filename, AssetOptions{MediaType: "application/zip", BaseURL: "https://dist.example.com/v0.1.2/"})
+	if err != nil {
+		t.Fatalf("NewAsset: %v", err)
+	}
+
+	// sha256("archive")
+	if asset.GetSha256() != "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3" {
+		t.Fatalf("sha256 = %q", asset.GetSha256())
+	}
+	if asset.GetSizeBytes() != int64(len("archive")) {
+		t.Fatalf("size = %d", asset.GetSizeBytes())
+	}
+	if asset.GetHref() != "https://dist.example.com/v0.1.2/"+filename {
+		t.Fatalf("href = %q", asset.GetHref())
+	}
+	if asset.GetSignatureHref() != asset.GetHref()+".sig" {
+		t.Fatalf("signature href = %q", asset.GetSignatureHref())
+	}
+	if asset.HasCertificateHref() {
+		t.Fatalf("certificate href = %q, want unset without .cert file", asset.GetCertificateHref())
+	}
+
+	attestations := asset.GetAttestations()
+	if len(attestations) != 2 {
+		t.Fatalf("attestations = %d, want 2", len(attestations))
+	}
+	if attestations[0].GetPredicateType() != PredicateTypeSLSAProvenanceV1 || attestations[1].GetPredicateType() != PredicateTypeSPDX {
+		t.Fatalf("predicate types = %q, %q", attestations[0].GetPredicateType(), attestations[1].GetPredicateType())
+	}
+	if attestations[1].GetBundleHref() != asset.GetHref()+SBOMBundleSuffix {
+		t.Fatalf("sbom bundle href = %q", attestations[1].GetBundleHref())
+	}
+}
+
+func TestNewAssetAssumeSignedUsesGivenHash(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.tar.gz", "archive")
+
+	asset, err := NewAsset(dir, "a.tar.gz", AssetOptions{SHA256: "abc", BaseURL: "https://dist.example.com", AssumeSigned: true})
+	if err != nil {
+		t.Fatalf("NewAsset: %v", err)
+	}
+	if asset.GetSha256() != "abc" {
+		t.Fatalf("sha256 = %q, want the supplied hash", asset.GetSha256())
+	}
+	if asset.GetCertificateHref() != "https://dist.example.com/a.tar.gz.cert" {
+		t.Fatalf("certificate href = %q", asset.GetCertificateHref())
+	}
+	if len(asset.GetAttestations()) != 0 {
+		t.Fatalf("attestations = %d, want 0", len(asset.GetAttestations()))
+	}
+}
+
+func TestSummarizeAssetAttestations(t *testing.T) {
+	asset := func(predicateTypes ...string) *pb.Asset {
+		var attestations []*pb.AttestationDescriptor
+		for _, predicateType := range predicateTypes {
+			attestations = append(attestations, NewAttestation(predicateType, "https://dist.example.com/bundle"))
+		}
+		return pb.Asset_builder{Attestations: attestations}.Build()
+	}
+	assets := map[string]*pb.Asset{
+		"linux-amd64":   asset(PredicateTypeSPDX, PredicateTypeSLSAProvenanceV1),
+		"windows-amd64": asset(PredicateTypeSLSAProvenanceV1),
+		"checksums":     asset(),
+	}
+
+	summary := SummarizeAssetAttestations(assets)
+	if len(summary) != 2 || summary[0].GetPredicateType() != PredicateTypeSLSAProvenanceV1 || summary[1].GetPredicateType() != PredicateTypeSPDX {
+		t.Fatalf("summary = %v", summary)
+	}
+	if summary[0].HasBundleHref() || summary[0].GetAttestationType() != AttestationTypeInTotoV1 {
+		t.Fatalf("summary[0] = %v, want an in-toto descriptor without a bundle", summary[0])
+	}
+
+	problems := CheckAttestationSets(assets)
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "windows-amd64: has no "+PredicateTypeSPDX) {
+		t.Fatalf("problems = %q", problems)
+	}
+	assets["windows-amd64"] = asset(PredicateTypeSLSAProvenanceV1, PredicateTypeSPDX)
+	if problems := CheckAttestationSets(assets); len(problems) != 0 {
+		t.Fatalf("problems = %q, want none", problems)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	version := SchemaVersion
+	m := pb.Manifest_builder{
+		Version:          &version,
+		AssetAttestation: NewAttestation(PredicateTypeSLSAProvenanceV1, ""),
+	}.Build()
+
+	data, err := Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// EmitUnpopulated keeps every field present for frontend consumers.
+	if !strings.Contains(string(data), `"semver"`) {
+		t.Fatalf("unpopulated fields missing from output:\n%s", data)
+	}
+
+	withUnknown := strings.TrimSuffix(string(data), "}") + `,"futureField":1}`
+	got := &pb.Manifest{}
+	if err := Unmarshal([]byte(withUnknown), got); err != nil {
+		t.Fatalf("Unmarshal with unknown field: %v", err)
+	}
+	if !proto.Equal(got, m) {
+		t.Fatalf("round trip mismatch:\n got %v\nwant %v", got, m)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAssetPatternInfersMediaType(t *testing.T) {
+	p, err := ParseAssetPattern("linux-amd64-zst=*linux-amd64.tar.zst")
+	if err != nil {
+		t.Fatalf("ParseAssetPattern: %v", err)
+	}
+	if p.Key != "linux-amd64-zst" || p.Pattern != "*linux-amd64.tar.zst" || p.MediaType != "application/zstd" {
+		t.Fatalf("pattern = %+v", p)
+	}
+
+	p, err = ParseAssetPattern("sbom=*.spdx.json,application/spdx+json")
+	if err != nil {
+		t.Fatalf("ParseAssetPattern with media type: %v", err)
+	}
+	if p.MediaType != "application/spdx+json" {
+		t.Fatalf("media type = %q", p.MediaType)
+	}
+
+	for _, bad := range []string{"no-equals", "=*.zip", "key=", "key=*.unknown", "key=[.zip"} {
+		if _, err := ParseAssetPattern(bad); err == nil {
+			t.Errorf("ParseAssetPattern(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLoadAssetPatterns(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "patterns.json", `{"assets": [
+		{"key": "linux-arm", "pattern": "*linux-arm.tar.gz"},
+		{"key": "windows-arm64", "pattern": "*windows-arm64.zip", "mediaType": "application/zip"}
+	]}`)
+	writeFile(t, dir, "typo.json", `{"asset": []}`)
+
+	patterns, err := LoadAssetPatterns(filepath.Join(dir, "patterns.json"))
+	if err != nil {
+		t.Fatalf("LoadAssetPatterns: %v", err)
+	}
+	if len(patterns) != 2 || patterns[0].MediaType != "application/gzip" {
+		t.Fatalf("patterns = %+v", patterns)
+	}
+	if _, err := LoadAssetPatterns(filepath.Join(dir, "typo.json")); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestParseImageRule(t *testing.T) {
+	r, err := ParseImageRule("ghcr=ghcr.io/conductorone/,{version},index")
+	if err != nil {
+		t.Fatalf("ParseImageRule: %v", err)
+	}
+	if r.Key != "ghcr" || r.Prefix != "ghcr.io/conductorone/" || !r.Index || r.Input != ImageInputImages {
+		t.Fatalf("rule = %+v", r)
+	}
+	if tag, ok := r.MatchTag("ghcr.io/conductorone/baton-example:0.1.2", "0.1.2"); !ok || tag != "0.1.2" {
+		t.Fatalf("MatchTag = %q, %v", tag, ok)
+	}
+	for _, ref := range []string{"ghcr.io/conductorone/baton-example:0.1.2-arm64", "ghcr.io/other/baton-example:0.1.2", "ghcr.io/conductorone/baton-example"} {
+		if _, ok := r.MatchTag(ref, "0.1.2"); ok {
+			t.Errorf("MatchTag(%q) matched", ref)
+		}
+	}
+
+	for _, bad := range []string{"ghcr", "ghcr=ghcr.io/,{version}", "ghcr=ghcr.io/,{version},multi", "=ghcr.io/,{version},index", "ghcr=ghcr.io/,[,index"} {
+		if _, err := ParseImageRule(bad); err == nil {
+			t.Errorf("ParseImageRule(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLoadImageRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "rules.json", `{"images": [
+		{"key": "ghcr", "prefix": "ghcr.io/conductorone/", "tag": "{version}", "index": true},
+		{"key": "lambda-amd64", "tag": "{version}-amd64", "input": "lambda", "optional": true}
+	]}`)
+	writeFile(t, dir, "bad-input.json", `{"images": [{"key": "k", "tag": "{version}", "input": "helm"}]}`)
+
+	rules, err := LoadImageRules(filepath.Join(dir, "rules.json"))
+	if err != nil {
+		t.Fatalf("LoadImageRules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Input != ImageInputImages || rules[1].Input != ImageInputLambda || !rules[1].Optional {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if _, err := LoadImageRules(filepath.Join(dir, "bad-input.json")); err == nil {
+		t.Fatal("expected error for unknown input")
+	}
+}
+
+func TestMergeAssetPatterns(t *testing.T) {
+	base := []AssetPattern{{Key: "a", Pattern: "*a.zip"}, {Key: "b", Pattern: "*b.zip"}}
+	got := MergeAssetPatterns(base, AssetPattern{Key: "b", Pattern: "*b.tar.zst"}, AssetPattern{Key: "c", Pattern: "*c.zip"})
+	if len(got) != 3 || got[1].Pattern != "*b.tar.zst" || got[2].Key != "c" {
+		t.Fatalf("merged = %+v", got)
+	}
+	if base[1].Pattern != "*b.zip" {
+		t.Fatal("MergeAssetPatterns modified its input")
+	}
+}
+
+func TestUnmatchedArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"baton-example-v0.1.2-linux-amd64.tar.gz",
+		"baton-example-v0.1.2-linux-amd64.tar.gz.sig",
+		"baton-example-v0.1.2-linux-amd64.tar.gz" + ProvenanceBundleSuffix,
+		"baton-example-v0.1.2-linux-arm.tar.gz",
+		"baton-example-v0.1.2-freebsd-riscv64.tar.zst",
+		"baton-example_0.1.2_checksums.txt",
+		"artifacts.json",
+	} {
+		writeFile(t, dir, name, "x")
+	}
+
+	got, err := UnmatchedArtifacts(dir, DefaultAssetPatterns)
+	if err != nil {
+		t.Fatalf("UnmatchedArtifacts: %v", err)
+	}
+	want := []string{"baton-example-v0.1.2-freebsd-riscv64.tar.zst"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unmatched = %v, want %v", got, want)
+	}
+}
+
+func TestFilenameHasVersion(t *testing.T) {
+	tests := []struct {
+		filename string
+		tag      string
+		want     bool
+	}{
+		{"baton-example-v0.1.2-linux-amd64.tar.gz", "v0.1.2", true},
+		{"baton-example_0.1.2_checksums.txt", "v0.1.2", true},
+		{"baton-example_v0.1.2_windows_amd64.msi", "v0.1.2", true},
+		{"baton-example-v0.1.1-linux-amd64.tar.gz", "v0.1.2", false},
+		{"baton-example-v0.1.20-linux-amd64.tar.gz", "v0.1.2", false},
+		{"baton-example_10.1.2_checksums.txt", "v0.1.2", false},
+		{"baton-example-linux-amd64.tar.gz", "v0.1.2", false},
+	}
+	for _, tt := range tests {
+		if got := FilenameHasVersion(tt.filename, tt.tag); got != tt.want {
+			t.Errorf("FilenameHasVersion(%q, %q) = %v, want %v", tt.filename, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "good.tar.gz", "archive")
+	writeFile(t, dir, "swapped.zip", "other")
+	writeFile(t, dir, "unlisted.zip", "extra")
+	checksums := map[string]string{
+		"good.tar.gz": "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3",
+		"swapped.zip": "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3",
+		"missing.zip": "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3",
+	}
+
+	problems, err := VerifyChecksums(dir, checksums, []string{"good.tar.gz", "swapped.zip", "unlisted.zip"})
+	if err != nil {
+		t.Fatalf("VerifyChecksums: %v", err)
+	}
+	if len(problems) != 3 {
+		t.Fatalf("problems = %v, want 3", problems)
+	}
+	if problems[0].Filename != "missing.zip" || problems[0].Actual != "" {
+		t.Fatalf("problems[0] = %+v, want missing.zip missing from disk", problems[0])
+	}
+	if problems[1].Filename != "swapped.zip" || problems[1].Actual == "" || problems[1].Actual == problems[1].Expected {
+		t.Fatalf("problems[1] = %+v, want swapped.zip mismatch", problems[1])
+	}
+	if !strings.Contains(problems[1].String(), "+ actual:") {
+		t.Fatalf("mismatch message = %q, want a diff", problems[1].String())
+	}
+	if problems[2].Filename != "unlisted.zip" || problems[2].Expected != "" || !strings.Contains(problems[2].String(), "not listed") {
+		t.Fatalf("problems[2] = %+v, want unlisted.zip missing from checksums", problems[2])
+	}
+
+	if _, err := VerifyChecksums(dir, checksums, []string{"nope.zip"}); err == nil {
+		t.Fatal("expected error hashing a missing matched file")
+	}
+}
+
+func lintTestManifest() *pb.Manifest {
+	size := int64(7)
+	isIndex := true
+	digest := "sha256:" + strings.Repeat("a", 64)
+	return pb.Manifest_builder{
+		Version: stringPtr(SchemaVersion),
+		Name:    stringPtr("baton-example"),
+		Org:     stringPtr("ConductorOne"),
+		Semver:  stringPtr("v0.1.2"),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{
+				Filename:     stringPtr("baton-example-v0.1.2-linux-amd64.tar.gz"),
+				SizeBytes:    &size,
+				Sha256:       stringPtr("0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3"),
+				Href:         stringPtr("https://dist.example.com/baton-example-v0.1.2-linux-amd64.tar.gz"),
+				Attestations: []*pb.AttestationDescriptor{NewAttestation(PredicateTypeSLSAProvenanceV1, "https://dist.example.com/a.provenance.sigstore.json")},
+			}.Build(),
+		},
+		Images: map[string]*pb.Image{
+			"ecrPublic": pb.Image_builder{
+				Ref:     stringPtr("public.ecr.aws/conductorone/baton-example:0.1.2"),
+				Digest:  stringPtr(digest),
+				Uri:     stringPtr("public.ecr.aws/conductorone/baton-example@" + digest),
+				IsIndex: &isIndex,
+				Platforms: []*pb.ImagePlatform{pb.ImagePlatform_builder{
+					Os:           stringPtr("linux"),
+					Architecture: stringPtr("arm64"),
+					Digest:       stringPtr(digest),
+				}.Build()},
+			}.Build(),
+		},
+		ImageAttestation: NewAttestation(PredicateTypeSLSAProvenanceV1, ""),
+	}.Build()
+}
+
+func lintFields(errs []LintError) string {
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+func TestLintManifestValid(t *testing.T) {
+	m := lintTestManifest()
+	if errs := LintManifest(m); len(errs) != 0 {
+		t.Fatalf("LintManifest = %v, want no errors", errs)
+	}
+	if errs := LintManifestPath(m, "releases/conductorone/baton-example/v0.1.2/manifest.json"); len(errs) != 0 {
+		t.Fatalf("LintManifestPath = %v, want no errors", errs)
+	}
+}
+
+func TestLintManifestReportsViolations(t *testing.T) {
+	m := lintTestManifest()
+	m.SetSemver("0.1.2")
+	m.ClearImageAttestation()
+	asset := m.GetAssets()["linux-amd64"]
+	asset.SetSha256("ABC")
+	asset.SetSizeBytes(0)
+	asset.SetHref("http://dist.example.com/a.tar.gz")
+	asset.GetAttestations()[0].SetPredicateType("https://example.com/unknown")
+	image := m.GetImages()["ecrPublic"]
+	image.SetUri("public.ecr.aws/conductorone/other@" + image.GetDigest())
+	image.GetPlatforms()[0].SetDigest("sha256:abc")
+
+	want := "semver," +
+		"assets.linux-amd64.sha256,assets.linux-amd64.size_bytes,assets.linux-amd64.href," +
+		"assets.linux-amd64.attestations[0].predicate_type," +
+		"images.ecrPublic.uri,images.ecrPublic.platforms[0].digest,image_attestation"
+	if got := lintFields(LintManifest(m)); got != want {
+		t.Fatalf("fields = %s\nwant     %s", got, want)
+	}
+}
+
+func TestLintStablePath(t *testing.T) {
+	stable := pb.Stable_builder{Version: stringPtr(StableSchemaVersion), Manifest: lintTestManifest()}.Build()
+	if got := lintFields(LintStable(stable)); got != "updated_at" {
+		t.Fatalf("LintStable fields = %s, want updated_at", got)
+	}
+	if errs := LintStablePath(stable, "releases/ConductorOne/baton-example/stable.json"); len(errs) != 0 {
+		t.Fatalf("LintStablePath = %v, want no errors", errs)
+	}
+	if got := lintFields(LintStablePath(stable, "releases/ConductorOne/baton-other/stable.json")); got != "manifest.name" {
+		t.Fatalf("LintStablePath fields = %s, want manifest.name", got)
+	}
+}
+
+// writeTestArchive packages a README and the running test binary, which
+// carries Go build info, as a .zip or .tar.gz in dir.
+func writeTestArchive(t *testing.T, dir, name string) string {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{"README.md", []byte("# baton-example\n")},
+		{"baton-example", binary},
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(name, ".zip") {
+		zw := zip.NewWriter(f)
+		for _, e := range entries {
+			w, err := zw.Create(e.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(e.data)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o755, Size: int64(len(e.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(e.data)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadArchiveBuildInfo(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"baton-example-v0.1.2-linux-amd64.tar.gz", "baton-example-v0.1.2-windows-amd64.zip"} {
+		info, binary, err := ReadArchiveBuildInfo(writeTestArchive(t, dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if binary != "baton-example" {
+			t.Errorf("%s: binary = %q, want baton-example", name, binary)
+		}
+		if info.GoVersion != runtime.Version() {
+			t.Errorf("%s: GoVersion = %q, want %q", name, info.GoVersion, runtime.Version())
+		}
+	}
+}
+
+func TestReadArchiveBuildInfoWithoutBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("README.md")
+	w.Write([]byte("MZ is not enough to be a binary\n"))
+	zw.Close()
+	f.Close()
+
+	if _, _, err := ReadArchiveBuildInfo(path); !errors.Is(err, ErrNoGoBinary) {
+		t.Fatalf("err = %v, want ErrNoGoBinary", err)
+	}
+}
+
+func TestSetBuildInfo(t *testing.T) {
+	asset := &pb.Asset{}
+	SetBuildInfo(asset, &debug.BuildInfo{
+		GoVersion: "go1.25.2",
+		Main:      debug.Module{Path: "github.com/conductorone/baton-example", Version: "v0.1.2"},
+		Deps: []*debug.Module{
+			{Path: "github.com/conductorone/baton-sdk", Version: "v0.3.35", Sum: "h1:abc="},
+			{Path: "golang.org/x/net", Version: "v0.1.0", Replace: &debug.Module{Path: "golang.org/x/net", Version: "v0.2.0", Sum: "h1:def="}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-trimpath", Value: "true"},
+			{Key: "CGO_ENABLED", Value: "0"},
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.modified", Value: "false"},
+		},
+	})
+
+	if asset.GetGoVersion() != "go1.25.2" || asset.GetMainModule().GetVersion() != "v0.1.2" {
+		t.Errorf("go version, main module = %q, %q", asset.GetGoVersion(), asset.GetMainModule().GetVersion())
+	}
+	if asset.GetVcsRevision() != "0123456789abcdef" {
+		t.Errorf("vcs revision = %q", asset.GetVcsRevision())
+	}
+	if asset.GetBuildSettings()["CGO_ENABLED"] != "0" || asset.GetBuildSettings()["-trimpath"] != "true" {
+		t.Errorf("build settings = %v", asset.GetBuildSettings())
+	}
+	deps := asset.GetDependencies()
+	if len(deps) != 2 || deps[0].GetSum() != "h1:abc=" || deps[1].GetReplace().GetVersion() != "v0.2.0" {
+		t.Errorf("dependencies = %v", deps)
+	}
+}
+
+func TestVerifyManifestRevisions(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	built := func(filename, revision, modified string) *pb.Asset {
+		asset := pb.Asset_builder{Filename: stringPtr(filename)}.Build()
+		settings := []debug.BuildSetting{{Key: "vcs.modified", Value: modified}}
+		if revision != "" {
+			settings = append(settings, debug.BuildSetting{Key: "vcs.revision", Value: revision})
+		}
+		SetBuildInfo(asset, &debug.BuildInfo{GoVersion: "go1.25.2", Settings: settings})
+		return asset
+	}
+	m := pb.Manifest_builder{Assets: map[string]*pb.Asset{
+		"darwin-arm64":      built("a-darwin-arm64.zip", strings.ToUpper(commit), "false"),
+		"linux-amd64":       built("a-linux-amd64.tar.gz", "ffffffffffffffffffffffffffffffffffffffff", "false"),
+		"linux-arm64":       built("a-linux-arm64.tar.gz", commit, "true"),
+		"windows-amd64":     built("a-windows-amd64.zip", "", "false"),
+		"freebsd-amd64":     pb.Asset_builder{Filename: stringPtr("a-freebsd-amd64.tar.gz")}.Build(),
+		"windows-amd64-msi": pb.Asset_builder{Filename: stringPtr("a-windows-amd64.msi")}.Build(),
+		"checksums":         pb.Asset_builder{Filename: stringPtr("a_checksums.txt")}.Build(),
+	}}.Build()
+
+	got := strings.Join(VerifyManifestRevisions(m, commit), "\n")
+	for _, want := range []string{
+		"freebsd-amd64: a-freebsd-amd64.tar.gz has no build info recorded",
+		"linux-amd64: a-linux-amd64.tar.gz was built from ffffffff",
+		"linux-arm64: a-linux-arm64.tar.gz was built from a modified working tree",
+		"windows-amd64: a-windows-amd64.zip was built without VCS stamping",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "darwin-arm64") || strings.Contains(got, "msi") || strings.Contains(got, "checksums") {
+		t.Errorf("unexpected problems:\n%s", got)
+	}
+}
+
+func TestMarshalIsCanonical(t *testing.T) {
+	m := lintTestManifest()
+	first, err := Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := Marshal(proto.Clone(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("Marshal output differs between runs:\n%s\n---\n%s", first, again)
+		}
+	}
+
+	// Compact protojson output of the same message canonicalizes to the same bytes.
+	compact, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromCompact, err := Canonicalize(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fromCompact) != string(first) {
+		t.Fatalf("canonical form depends on input formatting:\n%s\n---\n%s", first, fromCompact)
+	}
+
+	if !strings.HasPrefix(string(first), "{\n  \"assetAttestation\":") {
+		t.Fatalf("keys are not sorted:\n%.80s", first)
+	}
+}
+
+func TestCanonicalizeKeepsValuesVerbatim(t *testing.T) {
+	got, err := Canonicalize([]byte(`{"b":1.50,"a":"x&y<z>","c":[{"z":1,"y":2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": \"x&y<z>\",\n  \"b\": 1.50,\n  \"c\": [\n    {\n      \"y\": 2,\n      \"z\": 1\n    }\n  ]\n}"
+	if string(got) != want {
+		t.Fatalf("Canonicalize = %s, want %s", got, want)
+	}
+	if _, err := Canonicalize([]byte(`{} {}`)); err == nil {
+		t.Fatal("expected an error for trailing data")
+	}
+}
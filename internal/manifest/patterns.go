@@ -0,0 +1,216 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AssetPattern maps an asset key to the glob used to find it in a dist directory.
+type AssetPattern struct {
+	// Key is the platform/type identifier used in Manifest.assets (e.g., "linux-arm64").
+	Key string `json:"key"`
+	// Pattern is a filepath.Match glob relative to the dist directory.
+	Pattern string `json:"pattern"`
+	// MediaType is recorded on the asset. When empty it is inferred from Pattern.
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+// AssetPatternsConfig is the on-disk format of an asset patterns file.
+type AssetPatternsConfig struct {
+	Assets []AssetPattern `json:"assets"`
+}
+
+// DefaultAssetPatterns are the goreleaser archives published by the release workflow.
+// Platforms that a connector does not build are simply absent from its dist directory.
+var DefaultAssetPatterns = []AssetPattern{
+	{Key: "darwin-arm64", Pattern: "*darwin-arm64.zip", MediaType: "application/zip"},
+	{Key: "darwin-amd64", Pattern: "*darwin-amd64.zip", MediaType: "application/zip"},
+	{Key: "linux-arm64", Pattern: "*linux-arm64.tar.gz", MediaType: "application/gzip"},
+	{Key: "linux-amd64", Pattern: "*linux-amd64.tar.gz", MediaType: "application/gzip"},
+	{Key: "linux-arm", Pattern: "*linux-arm.tar.gz", MediaType: "application/gzip"},
+	{Key: "freebsd-arm64", Pattern: "*freebsd-arm64.tar.gz", MediaType: "application/gzip"},
+	{Key: "freebsd-amd64", Pattern: "*freebsd-amd64.tar.gz", MediaType: "application/gzip"},
+	{Key: "windows-amd64", Pattern: "*windows-amd64.zip", MediaType: "application/zip"},
+	{Key: "windows-arm64", Pattern: "*windows-arm64.zip", MediaType: "application/zip"},
+	{Key: "checksums", Pattern: ChecksumsPattern, MediaType: "text/plain"},
+}
+
+// archiveMediaTypes maps artifact file extensions to media types. Longer
+// extensions are listed first so ".tar.gz" wins over ".gz".
+var archiveMediaTypes = []struct {
+	ext       string
+	mediaType string
+}{
+	{".tar.gz", "application/gzip"},
+	{".tar.zst", "application/zstd"},
+	{".tar.xz", "application/x-xz"},
+	{".tgz", "application/gzip"},
+	{".zip", "application/zip"},
+	{".msi", "application/x-msi"},
+	{".deb", "application/vnd.debian.binary-package"},
+	{".rpm", "application/x-rpm"},
+	{".apk", "application/vnd.android.package-archive"},
+}
+
+// MediaTypeFor infers the media type of an artifact from its file name or glob.
+// It returns "" for names that do not look like a release artifact.
+func MediaTypeFor(name string) string {
+	for _, a := range archiveMediaTypes {
+		if strings.HasSuffix(name, a.ext) {
+			return a.mediaType
+		}
+	}
+	if strings.HasSuffix(name, ".txt") {
+		return "text/plain"
+	}
+	return ""
+}
+
+// LoadAssetPatterns reads an AssetPatternsConfig JSON file.
+func LoadAssetPatterns(path string) ([]AssetPattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading asset patterns: %w", err)
+	}
+	defer f.Close()
+
+	var cfg AssetPatternsConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing asset patterns %s: %w", path, err)
+	}
+	for i, p := range cfg.Assets {
+		p, err := normalizePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("asset patterns %s: entry %d: %w", path, i, err)
+		}
+		cfg.Assets[i] = p
+	}
+	return cfg.Assets, nil
+}
+
+// ParseAssetPattern parses a "key=pattern" or "key=pattern,mediaType" flag value.
+func ParseAssetPattern(s string) (AssetPattern, error) {
+	key, rest, ok := strings.Cut(s, "=")
+	if !ok {
+		return AssetPattern{}, fmt.Errorf("invalid asset pattern %q (expected key=pattern[,mediaType])", s)
+	}
+	pattern, mediaType, _ := strings.Cut(rest, ",")
+	return normalizePattern(AssetPattern{Key: key, Pattern: pattern, MediaType: mediaType})
+}
+
+// MergeAssetPatterns returns base with each override replacing the entry with the same key,
+// or appended when the key is new.
+func MergeAssetPatterns(base []AssetPattern, overrides ...AssetPattern) []AssetPattern {
+	merged := append([]AssetPattern(nil), base...)
+	for _, o := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == o.Key {
+				merged[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+func normalizePattern(p AssetPattern) (AssetPattern, error) {
+	p.Key = strings.TrimSpace(p.Key)
+	p.Pattern = strings.TrimSpace(p.Pattern)
+	p.MediaType = strings.TrimSpace(p.MediaType)
+	if p.Key == "" || p.Pattern == "" {
+		return p, fmt.Errorf("asset pattern requires both key and pattern (got key=%q pattern=%q)", p.Key, p.Pattern)
+	}
+	if _, err := filepath.Match(p.Pattern, ""); err != nil {
+		return p, fmt.Errorf("asset pattern %q for %s: %w", p.Pattern, p.Key, err)
+	}
+	if p.MediaType == "" {
+		p.MediaType = MediaTypeFor(p.Pattern)
+	}
+	if p.MediaType == "" {
+		return p, fmt.Errorf("cannot infer media type for %s pattern %q, set it explicitly", p.Key, p.Pattern)
+	}
+	return p, nil
+}
+
+// Glob returns the sorted base names of files in dir matching pattern.
+func Glob(dir, pattern string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, filepath.Base(m))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FilenameHasVersion reports whether filename embeds the release tag as a whole
+// name component: "-v1.2.3-" / "_v1.2.3_" as in archive and MSI names, or "_1.2.3_"
+// as in goreleaser's checksums file. The component must end at '-', '_' or '.', so
+// tag v1.2.1 does not match a leftover v1.2.10 artifact.
+func FilenameHasVersion(filename, tag string) bool {
+	version := strings.TrimPrefix(tag, "v")
+	if version == "" {
+		return false
+	}
+	for _, component := range []string{"v" + version, version} {
+		rest := filename
+		for {
+			i := strings.Index(rest, component)
+			if i < 0 {
+				break
+			}
+			before := byte('-')
+			if i > 0 {
+				before = rest[i-1]
+			}
+			after := rest[i+len(component):]
+			if (before == '-' || before == '_') && after != "" && strings.ContainsRune("-_.", rune(after[0])) {
+				return true
+			}
+			rest = rest[i+1:]
+		}
+	}
+	return false
+}
+
+// UnmatchedArtifacts returns the artifacts in dir (files with a known archive or
+// package extension) that none of patterns match. Sidecars such as .sig, .cert and
+// .sigstore.json bundles are not artifacts and are never reported.
+func UnmatchedArtifacts(dir string, patterns []AssetPattern) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var unmatched []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || MediaTypeFor(name) == "" || strings.HasSuffix(name, ".txt") {
+			continue
+		}
+		matched := false
+		for _, p := range patterns {
+			if ok, _ := filepath.Match(p.Pattern, name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, name)
+		}
+	}
+	return unmatched, nil
+}
@@ -0,0 +1,351 @@
+// Package oci reads OCI image layouts: the oci-layout marker, index.json and
+// the content-addressed blobs under blobs/sha256.
+//
+// Only the parts of the image spec the release manifest records are decoded:
+// descriptors, platforms and annotations.
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Media types of image indexes and manifests, in OCI and Docker form.
+const (
+	MediaTypeImageIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// Annotations and labels read from images.
+const (
+	AnnotationRefName   = "org.opencontainers.image.ref.name"
+	AnnotationRevision  = "org.opencontainers.image.revision"
+	AnnotationSource    = "org.opencontainers.image.source"
+	AnnotationImageName = "io.containerd.image.name"
+)
+
+// layoutVersion is the only imageLayoutVersion defined by the image spec.
+const layoutVersion = "1.0.0"
+
+var (
+	// ErrNotLayout is returned when a directory has no oci-layout file.
+	ErrNotLayout = errors.New("not an OCI image layout")
+	// ErrDigestMismatch is returned when a blob does not hash to its digest
+	// or its size differs from its descriptor.
+	ErrDigestMismatch = errors.New("blob does not match its descriptor")
+)
+
+// Platform is the os/architecture/variant an image manifest runs on.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String formats p as os/architecture[/variant].
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Matches reports whether a config's platform agrees with p. A config that
+// records no variant matches any variant, since builders often omit it (e.g.
+// arm64 configs for an index entry listed as arm64/v8).
+func (p Platform) Matches(config Platform) bool {
+	if p.OS != config.OS || p.Architecture != config.Architecture {
+		return false
+	}
+	return config.Variant == "" || p.Variant == config.Variant
+}
+
+// Descriptor points to a blob by digest.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// IsIndex reports whether d points to an image index or Docker manifest list.
+func (d Descriptor) IsIndex() bool {
+	return d.MediaType == MediaTypeImageIndex || d.MediaType == MediaTypeDockerList
+}
+
+// Index is an image index: the layout's index.json or a multi-arch image.
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is a single-platform image manifest.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Config is the part of an image config blob that describes its platform and labels.
+type Config struct {
+	Platform
+	Created string `json:"created,omitempty"`
+	Config  struct {
+		Labels map[string]string `json:"Labels,omitempty"`
+	} `json:"config"`
+}
+
+// Image is an image listed in a layout's index.json, with every blob it
+// references read and verified.
+type Image struct {
+	// Descriptor is the index.json entry for the image.
+	Descriptor Descriptor
+	// Annotations merges the descriptor's annotations over the image's own.
+	Annotations map[string]string
+	// Platforms lists the child manifests of an index, sorted by platform.
+	Platforms []PlatformManifest
+	// Config is the config of a single-platform image.
+	Config *Config
+}
+
+// PlatformManifest is one platform manifest of an index and its config.
+type PlatformManifest struct {
+	Platform Platform
+	Digest   string
+	Config   *Config
+}
+
+// Revision returns the image's source revision from its annotations, or from
+// the labels of its (first) config.
+func (img *Image) Revision() string {
+	return img.lookup(AnnotationRevision)
+}
+
+// Source returns the image's source repository URL from its annotations, or
+// from the labels of its (first) config.
+func (img *Image) Source() string {
+	return img.lookup(AnnotationSource)
+}
+
+func (img *Image) lookup(key string) string {
+	if v := img.Annotations[key]; v != "" {
+		return v
+	}
+	config := img.Config
+	if config == nil && len(img.Platforms) > 0 {
+		config = img.Platforms[0].Config
+	}
+	if config != nil {
+		return config.Config.Labels[key]
+	}
+	return ""
+}
+
+// Platforms returns the index's platform manifests sorted by platform.
+// Attestation manifests, which buildx lists under the unknown/unknown
+// platform, are skipped.
+func (idx *Index) Platforms() []Descriptor {
+	var platforms []Descriptor
+	for _, d := range idx.Manifests {
+		if d.Platform == nil || d.IsIndex() {
+			continue
+		}
+		if d.Platform.OS == "unknown" || d.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+			continue
+		}
+		platforms = append(platforms, d)
+	}
+	sort.SliceStable(platforms, func(i, j int) bool {
+		return platforms[i].Platform.String() < platforms[j].Platform.String()
+	})
+	return platforms
+}
+
+// Layout is an OCI image layout directory.
+type Layout struct {
+	Dir string
+}
+
+// OpenLayout checks that dir holds an OCI image layout.
+func OpenLayout(dir string) (*Layout, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "oci-layout"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", dir, ErrNotLayout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading oci-layout: %w", err)
+	}
+	var marker struct {
+		ImageLayoutVersion string `json:"imageLayoutVersion"`
+	}
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return nil, fmt.Errorf("parsing oci-layout in %s: %w", dir, err)
+	}
+	if marker.ImageLayoutVersion != layoutVersion {
+		return nil, fmt.Errorf("%s: unsupported image layout version %q", dir, marker.ImageLayoutVersion)
+	}
+	return &Layout{Dir: dir}, nil
+}
+
+// Index reads the layout's index.json.
+func (l *Layout) Index() (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(l.Dir, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading index.json: %w", err)
+	}
+	idx := &Index{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("parsing index.json in %s: %w", l.Dir, err)
+	}
+	return idx, nil
+}
+
+// Images reads every image listed in index.json: the index or manifest
+// blob, each platform manifest of an index, and their configs. Every blob is
+// hashed and checked against the descriptor that references it.
+func (l *Layout) Images() ([]*Image, error) {
+	idx, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+	images := make([]*Image, 0, len(idx.Manifests))
+	for _, d := range idx.Manifests {
+		img, err := l.readImage(d)
+		if err != nil {
+			return nil, fmt.Errorf("image %s: %w", d.Digest, err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func (l *Layout) readImage(d Descriptor) (*Image, error) {
+	img := &Image{Descriptor: d, Annotations: map[string]string{}}
+	var own map[string]string
+	if d.IsIndex() {
+		child, err := l.readIndex(d)
+		if err != nil {
+			return nil, err
+		}
+		own = child.Annotations
+		for _, m := range child.Platforms() {
+			config, err := l.readManifestConfig(m)
+			if err != nil {
+				return nil, err
+			}
+			if !m.Platform.Matches(config.Platform) {
+				return nil, fmt.Errorf("manifest %s is listed as %s but its config is %s", m.Digest, m.Platform, config.Platform)
+			}
+			img.Platforms = append(img.Platforms, PlatformManifest{Platform: *m.Platform, Digest: m.Digest, Config: config})
+		}
+	} else {
+		data, err := l.readDescriptor(d)
+		if err != nil {
+			return nil, err
+		}
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing manifest %s: %w", d.Digest, err)
+		}
+		own = m.Annotations
+		if img.Config, err = l.readConfig(m.Config); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range own {
+		img.Annotations[k] = v
+	}
+	for k, v := range d.Annotations {
+		img.Annotations[k] = v
+	}
+	return img, nil
+}
+
+func (l *Layout) readIndex(d Descriptor) (*Index, error) {
+	data, err := l.readDescriptor(d)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("parsing index %s: %w", d.Digest, err)
+	}
+	return idx, nil
+}
+
+func (l *Layout) readManifestConfig(d Descriptor) (*Config, error) {
+	data, err := l.readDescriptor(d)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", d.Digest, err)
+	}
+	return l.readConfig(m.Config)
+}
+
+func (l *Layout) readConfig(d Descriptor) (*Config, error) {
+	data, err := l.readDescriptor(d)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", d.Digest, err)
+	}
+	return config, nil
+}
+
+// readDescriptor reads the blob d points to and checks its size.
+func (l *Layout) readDescriptor(d Descriptor) ([]byte, error) {
+	data, err := l.ReadBlob(d.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != d.Size {
+		return nil, fmt.Errorf("%s is %d bytes, descriptor says %d: %w", d.Digest, len(data), d.Size, ErrDigestMismatch)
+	}
+	return data, nil
+}
+
+// ReadBlob reads the blob with the given "sha256:<hex>" digest and checks
+// that its content hashes to that digest.
+func (l *Layout) ReadBlob(digest string) ([]byte, error) {
+	path, err := l.blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if got := "sha256:" + hex.EncodeToString(sum[:]); got != digest {
+		return nil, fmt.Errorf("blob %s hashes to %s: %w", digest, got, ErrDigestMismatch)
+	}
+	return data, nil
+}
+
+func (l *Layout) blobPath(digest string) (string, error) {
+	encoded, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || encoded == "" || strings.ContainsAny(encoded, `/\.`) {
+		return "", fmt.Errorf("unsupported digest %q", digest)
+	}
+	return filepath.Join(l.Dir, "blobs", "sha256", encoded), nil
+}
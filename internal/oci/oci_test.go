@@ -0,0 +1,176 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeBlob stores v as JSON under blobs/sha256 and returns its descriptor.
+func writeBlob(t *testing.T, dir, mediaType string, v any) Descriptor {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	blobs := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(blobs, hex.EncodeToString(sum[:])), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
+
+// writeImage stores a manifest and config for platform and returns the manifest descriptor.
+func writeImage(t *testing.T, dir string, platform Platform, labels map[string]string) Descriptor {
+	t.Helper()
+	config := map[string]any{"os": platform.OS, "architecture": platform.Architecture, "variant": platform.Variant, "config": map[string]any{"Labels": labels}}
+	configDesc := writeBlob(t, dir, "application/vnd.oci.image.config.v1+json", config)
+	d := writeBlob(t, dir, MediaTypeImageManifest, Manifest{SchemaVersion: 2, MediaType: MediaTypeImageManifest, Config: configDesc})
+	d.Platform = &platform
+	return d
+}
+
+func writeLayout(t *testing.T, dir string, manifests ...Descriptor) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Index{SchemaVersion: 2, Manifests: manifests})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayoutImages(t *testing.T) {
+	dir := t.TempDir()
+	labels := map[string]string{AnnotationRevision: "abc123", AnnotationSource: "https://github.com/ConductorOne/baton-example"}
+	amd64 := writeImage(t, dir, Platform{OS: "linux", Architecture: "amd64"}, labels)
+	armv7 := writeImage(t, dir, Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, labels)
+	attestation := writeBlob(t, dir, MediaTypeImageManifest, Manifest{SchemaVersion: 2})
+	attestation.Platform = &Platform{OS: "unknown", Architecture: "unknown"}
+	attestation.Annotations = map[string]string{"vnd.docker.reference.type": "attestation-manifest"}
+	index := writeBlob(t, dir, MediaTypeImageIndex, Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageIndex,
+		Manifests:     []Descriptor{armv7, amd64, attestation},
+		Annotations:   map[string]string{AnnotationRevision: "def456"},
+	})
+	index.Annotations = map[string]string{AnnotationRefName: "0.1.2"}
+	single := writeImage(t, dir, Platform{OS: "linux", Architecture: "arm64"}, labels)
+	single.Platform = nil
+	writeLayout(t, dir, index, single)
+
+	layout, err := OpenLayout(dir)
+	if err != nil {
+		t.Fatalf("OpenLayout: %v", err)
+	}
+	images, err := layout.Images()
+	if err != nil {
+		t.Fatalf("Images: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("images = %d, want 2", len(images))
+	}
+
+	img := images[0]
+	if img.Descriptor.Digest != index.Digest || img.Annotations[AnnotationRefName] != "0.1.2" {
+		t.Fatalf("index image = %+v", img)
+	}
+	// The index's own annotation wins over the config labels.
+	if img.Revision() != "def456" || img.Source() != "https://github.com/ConductorOne/baton-example" {
+		t.Fatalf("revision, source = %q, %q", img.Revision(), img.Source())
+	}
+	if len(img.Platforms) != 2 || img.Platforms[0].Digest != amd64.Digest || img.Platforms[1].Platform.String() != "linux/arm/v7" {
+		t.Fatalf("platforms = %+v", img.Platforms)
+	}
+
+	if images[1].Config == nil || images[1].Config.Architecture != "arm64" || images[1].Revision() != "abc123" {
+		t.Fatalf("single-platform image = %+v", images[1])
+	}
+}
+
+func TestLayoutImagesVerifiesBlobs(t *testing.T) {
+	dir := t.TempDir()
+	d := writeImage(t, dir, Platform{OS: "linux", Architecture: "amd64"}, nil)
+	writeLayout(t, dir, d)
+	layout, err := OpenLayout(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := filepath.Join(dir, "blobs", "sha256", d.Digest[len("sha256:"):])
+	if err := os.WriteFile(blob, []byte(`{"schemaVersion": 2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := layout.Images(); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("err = %v, want ErrDigestMismatch for a modified blob", err)
+	}
+
+	d = writeImage(t, dir, Platform{OS: "linux", Architecture: "amd64"}, nil)
+	d.Size++
+	writeLayout(t, dir, d)
+	if _, err := layout.Images(); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("err = %v, want ErrDigestMismatch for a wrong size", err)
+	}
+}
+
+func TestLayoutImagesChecksConfigPlatform(t *testing.T) {
+	dir := t.TempDir()
+	d := writeImage(t, dir, Platform{OS: "linux", Architecture: "amd64"}, nil)
+	d.Platform = &Platform{OS: "linux", Architecture: "arm64"}
+	index := writeBlob(t, dir, MediaTypeImageIndex, Index{SchemaVersion: 2, Manifests: []Descriptor{d}})
+	writeLayout(t, dir, index)
+	layout, err := OpenLayout(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := layout.Images(); err == nil {
+		t.Fatal("expected an error when the index platform disagrees with the config")
+	}
+
+	// The variant is compared when the config records one.
+	d = writeImage(t, dir, Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, nil)
+	d.Platform = &Platform{OS: "linux", Architecture: "arm", Variant: "v6"}
+	writeLayout(t, dir, writeBlob(t, dir, MediaTypeImageIndex, Index{SchemaVersion: 2, Manifests: []Descriptor{d}}))
+	if _, err := layout.Images(); err == nil {
+		t.Fatal("expected an error when the index variant disagrees with the config")
+	}
+	d = writeImage(t, dir, Platform{OS: "linux", Architecture: "arm64"}, nil)
+	d.Platform = &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	writeLayout(t, dir, writeBlob(t, dir, MediaTypeImageIndex, Index{SchemaVersion: 2, Manifests: []Descriptor{d}}))
+	if _, err := layout.Images(); err != nil {
+		t.Fatalf("config without a variant: %v", err)
+	}
+}
+
+func TestOpenLayoutErrors(t *testing.T) {
+	if _, err := OpenLayout(t.TempDir()); !errors.Is(err, ErrNotLayout) {
+		t.Fatalf("err = %v, want ErrNotLayout", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion": "2.0.0"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenLayout(dir); err == nil {
+		t.Fatal("expected an error for an unsupported layout version")
+	}
+
+	layout := &Layout{Dir: dir}
+	for _, digest := range []string{"sha512:abc", "sha256:", "sha256:../../etc/passwd"} {
+		if _, err := layout.ReadBlob(digest); err == nil {
+			t.Errorf("ReadBlob(%q) succeeded", digest)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package registry submits release metadata to the connector registry ingest API.
+//
+// Requests are retried with exponential backoff and jitter on 429, 5xx and
+// network errors. Every attempt carries the same Idempotency-Key so the
+// registry can deduplicate a release recorded more than once.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IngestPath is the registry endpoint that records a release.
+const IngestPath = "/api/v1/ingest/release"
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values
+	// below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles for each
+	// attempt after that.
+	BaseDelay time.Duration
+	// MaxDelay caps both the computed backoff and a server's Retry-After.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries for roughly a minute before giving up.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// Client posts release records to the registry.
+type Client struct {
+	// Endpoint is the full ingest URL, see EndpointURL.
+	Endpoint string
+	// Token is sent as a Bearer token.
+	Token string
+	// HTTPClient sends each attempt; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+	// Logf reports retries; nothing is logged when nil.
+	Logf func(format string, args ...any)
+
+	// sleep waits between attempts; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Response is the registry's final answer to a request.
+type Response struct {
+	StatusCode int
+	Body       []byte
+	// Attempts is the number of requests sent, including the successful one.
+	Attempts int
+}
+
+// EndpointURL joins the ingest path onto a registry base URL.
+func EndpointURL(registryURL string) (string, error) {
+	base, err := url.Parse(registryURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing registry URL: %w", err)
+	}
+	return base.JoinPath(IngestPath).String(), nil
+}
+
+// IdempotencyKey derives a stable key for a release so that retries and
+// re-runs of the same release are recognized by the registry.
+func IdempotencyKey(org, name, version, commitSha string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{org, name, version, commitSha}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRequest builds a single POST of body with the authorization, content type
+// and idempotency headers set.
+func (c *Client) NewRequest(ctx context.Context, body []byte, idempotencyKey string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	return req, nil
+}
+
+// Post sends body to the ingest endpoint, retrying transient failures. Any HTTP
+// response that is not retried, or the last one once attempts are exhausted, is
+// returned without error so the caller can interpret the status code. An error
+// is returned only when no response was received.
+func (c *Client) Post(ctx context.Context, body []byte, idempotencyKey string) (*Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	sleep := c.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	maxAttempts := max(c.Retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, httpClient, body, idempotencyKey)
+		if err == nil {
+			resp.Attempts = attempt
+		}
+
+		var reason string
+		var retryAfter time.Duration
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil, err
+		case err != nil:
+			reason = err.Error()
+		case retryable(resp.StatusCode):
+			reason = fmt.Sprintf("HTTP %d", resp.StatusCode)
+			retryAfter = resp.retryAfter
+		default:
+			return &resp.Response, nil
+		}
+
+		if attempt >= maxAttempts {
+			if err != nil {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return &resp.Response, nil
+		}
+
+		delay := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			delay = c.Retry.cap(retryAfter)
+		}
+		if c.Logf != nil {
+			c.Logf("attempt %d/%d failed: %s; retrying in %s", attempt, maxAttempts, reason, delay.Round(time.Millisecond))
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type attemptResponse struct {
+	Response
+	retryAfter time.Duration
+}
+
+func (c *Client) do(ctx context.Context, httpClient *http.Client, body []byte, idempotencyKey string) (*attemptResponse, error) {
+	req, err := c.NewRequest(ctx, body, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return &attemptResponse{
+		Response:   Response{StatusCode: resp.StatusCode, Body: respBody},
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}, nil
+}
+
+// retryable reports whether a status code indicates a transient failure.
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+// backoff returns the delay after the given failed attempt: BaseDelay doubled
+// per attempt, capped at MaxDelay, with the upper half jittered.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	d = p.cap(d)
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+func (p RetryPolicy) cap(d time.Duration) time.Duration {
+	if p.MaxDelay > 0 {
+		return min(d, p.MaxDelay)
+	}
+	return d
+}
+
+// parseRetryAfter accepts either delay-seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
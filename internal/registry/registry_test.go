@@ -0,0 +1,330 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const (
+	inTotoStatement = "https://in-toto.io/Statement/v1"
+	slsaProvenance  = "https://slsa.dev/provenance/v1"
+	spdxDocument    = "https://spdx.dev/Document"
+)
+
+// flakyServer fails the first failures requests with status (or by dropping the
+// connection when status is 0) and then answers 200.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32, *atomic.Value) {
+	t.Helper()
+	var calls atomic.Int32
+	var keys atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if prev, ok := keys.Load().(string); ok && prev != r.Header.Get("Idempotency-Key") {
+			t.Errorf("attempt %d Idempotency-Key = %q, want %q", n, r.Header.Get("Idempotency-Key"), prev)
+		}
+		keys.Store(r.Header.Get("Idempotency-Key"))
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if n <= failures {
+			if status == 0 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+				return
+			}
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &keys
+}
+
+func testClient(srv *httptest.Server, attempts int) (*Client, *[]time.Duration) {
+	var slept []time.Duration
+	c := &Client{
+		Endpoint:   srv.URL + IngestPath,
+		Token:      "test-token",
+		HTTPClient: srv.Client(),
+		Retry:      RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Second, MaxDelay: 30 * time.Second},
+		sleep: func(_ context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		},
+	}
+	return c, &slept
+}
+
+func TestPostRetriesServerErrors(t *testing.T) {
+	srv, calls, keys := flakyServer(t, 3, http.StatusServiceUnavailable, nil)
+	c, slept := testClient(srv, 5)
+
+	key := IdempotencyKey("ConductorOne", "baton-example", "v0.1.2", "abc123")
+	resp, err := c.Post(context.Background(), []byte(`{}`), key)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Attempts != 4 || calls.Load() != 4 {
+		t.Fatalf("status %d after %d attempts (%d calls), want 200 after 4", resp.StatusCode, resp.Attempts, calls.Load())
+	}
+	if keys.Load() != key {
+		t.Fatalf("Idempotency-Key = %v, want %s", keys.Load(), key)
+	}
+	// Each delay lies in the upper half of the doubled base delay.
+	for i, d := range *slept {
+		ceiling := time.Second << i
+		if d < ceiling/2 || d > ceiling {
+			t.Errorf("delay %d = %s, want between %s and %s", i, d, ceiling/2, ceiling)
+		}
+	}
+}
+
+func TestPostRetriesDroppedConnections(t *testing.T) {
+	srv, calls, _ := flakyServer(t, 2, 0, nil)
+	c, _ := testClient(srv, 5)
+
+	resp, err := c.Post(context.Background(), []byte(`{}`), "key")
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestPostHonorsRetryAfter(t *testing.T) {
+	srv, _, _ := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}})
+	c, slept := testClient(srv, 3)
+
+	if _, err := c.Post(context.Background(), []byte(`{}`), "key"); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 7*time.Second {
+		t.Fatalf("slept %v, want [7s]", *slept)
+	}
+}
+
+func TestPostReturnsLastResponseWhenAttemptsExhausted(t *testing.T) {
+	srv, calls, _ := flakyServer(t, 10, http.StatusBadGateway, nil)
+	c, _ := testClient(srv, 3)
+
+	resp, err := c.Post(context.Background(), []byte(`{}`), "key")
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 3 {
+		t.Fatalf("status %d after %d calls, want 502 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestPostDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusConflict} {
+		srv, calls, _ := flakyServer(t, 1, status, nil)
+		c, _ := testClient(srv, 5)
+
+		resp, err := c.Post(context.Background(), []byte(`{}`), "key")
+		if err != nil {
+			t.Fatalf("Post: %v", err)
+		}
+		if resp.StatusCode != status || calls.Load() != 1 {
+			t.Fatalf("status %d after %d calls, want %d after 1", resp.StatusCode, calls.Load(), status)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"12":                            12 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Thu, 02 Jan 2025 03:04:35 GMT": 30 * time.Second,
+		"Thu, 02 Jan 2025 03:00:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestIdempotencyKeyDependsOnEveryField(t *testing.T) {
+	base := IdempotencyKey("org", "name", "v1.0.0", "sha")
+	if base != IdempotencyKey("org", "name", "v1.0.0", "sha") {
+		t.Fatal("IdempotencyKey is not deterministic")
+	}
+	for _, other := range []string{
+		IdempotencyKey("org2", "name", "v1.0.0", "sha"),
+		IdempotencyKey("org", "name2", "v1.0.0", "sha"),
+		IdempotencyKey("org", "name", "v1.0.1", "sha"),
+		IdempotencyKey("org", "name", "v1.0.0", "sha2"),
+		IdempotencyKey("orgn", "ame", "v1.0.0", "sha"),
+	} {
+		if other == base {
+			t.Fatalf("IdempotencyKey collision: %s", other)
+		}
+	}
+}
+
+func TestTransformAssetsPreservesAssetAttestations(t *testing.T) {
+	sizeBytes := int64(123)
+	manifest := pb.Manifest_builder{
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{
+				Filename:  strPtr("baton-example-v1.2.3-linux-amd64.tar.gz"),
+				MediaType: strPtr("application/gzip"),
+				SizeBytes: &sizeBytes,
+				Sha256:    strPtr("asset-sha"),
+				Href:      strPtr("https://dist.example.com/asset.tar.gz"),
+				Attestations: []*pb.AttestationDescriptor{
+					attestation(slsaProvenance, "https://dist.example.com/provenance.sigstore.json"),
+					attestation(spdxDocument, "https://dist.example.com/sbom.sigstore.json"),
+				},
+			}.Build(),
+		},
+	}.Build()
+
+	assets := TransformAssets(manifest)
+	got := assets["linux-amd64"].Attestations
+	want := []*ReleaseAttestation{
+		{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
+		{Type: spdxDocument, URL: "https://dist.example.com/sbom.sigstore.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("attestations = %#v, want %#v", got, want)
+	}
+}
+
+func TestTransformAttestationsSkipsIncompleteAssetEntries(t *testing.T) {
+	got := transformAttestations([]*pb.AttestationDescriptor{
+		attestation(slsaProvenance, "https://dist.example.com/provenance.sigstore.json"),
+		attestation("", "https://dist.example.com/missing-predicate.sigstore.json"),
+		attestation(spdxDocument, ""),
+	})
+	want := []*ReleaseAttestation{
+		{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("attestations = %#v, want %#v", got, want)
+	}
+}
+
+func TestTransformImagesAppliesManifestImageAttestation(t *testing.T) {
+	isIndex := true
+	manifest := pb.Manifest_builder{
+		ImageAttestation: attestation(slsaProvenance, ""),
+		Images: map[string]*pb.Image{
+			"ecrPublic": pb.Image_builder{
+				Ref:     strPtr("public.ecr.aws/example/baton-example:v1.2.3"),
+				Digest:  strPtr("sha256:ecr"),
+				IsIndex: &isIndex,
+			}.Build(),
+		},
+	}.Build()
+
+	images := TransformImages(manifest)
+	for platform, image := range images {
+		want := []*ReleaseAttestation{{Type: slsaProvenance}}
+		if !reflect.DeepEqual(image.Attestations, want) {
+			t.Fatalf("%s attestations = %#v, want %#v", platform, image.Attestations, want)
+		}
+	}
+}
+
+func TestTransformImagesSkipsAttestationForNonIndexImage(t *testing.T) {
+	isIndex := false
+	manifest := pb.Manifest_builder{
+		ImageAttestation: attestation(slsaProvenance, ""),
+		Images: map[string]*pb.Image{
+			"lambda-arm64": pb.Image_builder{
+				Ref:     strPtr("baton-example:1.2.3-arm64"),
+				Digest:  strPtr("sha256:lambda"),
+				IsIndex: &isIndex,
+			}.Build(),
+		},
+	}.Build()
+
+	images := TransformImages(manifest)
+	if len(images["lambda-arm64"].Attestations) != 0 {
+		t.Fatalf("lambda attestations = %#v, want none", images["lambda-arm64"].Attestations)
+	}
+}
+
+func TestRecordReleaseRequestMarshalsAttestations(t *testing.T) {
+	req := &RecordReleaseRequest{
+		Org:     "example",
+		Name:    "baton-example",
+		Version: "v1.2.3",
+		Assets: map[string]*ReleaseAsset{
+			"linux-amd64": {
+				Platform: "linux-amd64",
+				Attestations: []*ReleaseAttestation{
+					{Type: slsaProvenance, URL: "https://dist.example.com/provenance.sigstore.json"},
+				},
+			},
+		},
+		Images: map[string]*ReleaseImage{
+			"ecrPublic": {
+				Platform:     "ecrPublic",
+				Attestations: []*ReleaseAttestation{{Type: slsaProvenance}},
+			},
+		},
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	var got struct {
+		Assets map[string]struct {
+			Attestations []ReleaseAttestation `json:"attestations"`
+		} `json:"assets"`
+		Images map[string]struct {
+			Attestations []ReleaseAttestation `json:"attestations"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+
+	if len(got.Assets["linux-amd64"].Attestations) != 1 {
+		t.Fatalf("asset attestations = %#v, want one entry", got.Assets["linux-amd64"].Attestations)
+	}
+	if got.Assets["linux-amd64"].Attestations[0].URL == "" {
+		t.Fatal("asset attestation URL was not marshaled")
+	}
+	if len(got.Images["ecrPublic"].Attestations) != 1 {
+		t.Fatalf("image attestations = %#v, want one entry", got.Images["ecrPublic"].Attestations)
+	}
+	if got.Images["ecrPublic"].Attestations[0].URL != "" {
+		t.Fatalf("image attestation URL = %q, want empty", got.Images["ecrPublic"].Attestations[0].URL)
+	}
+}
+
+func attestation(predicateType, bundleHref string) *pb.AttestationDescriptor {
+	return pb.AttestationDescriptor_builder{
+		AttestationType: strPtr(inTotoStatement),
+		PredicateType:   strPtr(predicateType),
+		BundleHref:      strPtr(bundleHref),
+	}.Build()
+}
+
+func strPtr(s string) *string {
+	return &s
+}
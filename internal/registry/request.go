@@ -0,0 +1,123 @@
+package registry
+
+import pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+
+// RecordReleaseRequest is the JSON body sent to the registry API.
+type RecordReleaseRequest struct {
+	Org            string                   `json:"org"`
+	Name           string                   `json:"name"`
+	Version        string                   `json:"version"`
+	RepositoryURL  string                   `json:"repositoryUrl"`
+	CommitSha      string                   `json:"commitSha"`
+	WorkflowRunID  string                   `json:"workflowRunId"`
+	Documentation  string                   `json:"documentation,omitempty"`
+	Changelog      string                   `json:"changelog,omitempty"`
+	ConfigSchema   string                   `json:"configSchema,omitempty"`
+	Capabilities   string                   `json:"capabilities,omitempty"`
+	SignatureURL   string                   `json:"signatureUrl,omitempty"`
+	CertificateURL string                   `json:"certificateUrl,omitempty"`
+	Assets         map[string]*ReleaseAsset `json:"assets,omitempty"`
+	Images         map[string]*ReleaseImage `json:"images,omitempty"`
+	ReleasedAt     string                   `json:"releasedAt,omitempty"`
+}
+
+// ReleaseAsset is the transformed asset for the registry API.
+type ReleaseAsset struct {
+	Platform       string                `json:"platform"`
+	Filename       string                `json:"filename"`
+	MediaType      string                `json:"mediaType"`
+	SizeBytes      int64                 `json:"sizeBytes"`
+	Sha256         string                `json:"sha256"`
+	DownloadURL    string                `json:"downloadUrl"`
+	SignatureURL   string                `json:"signatureUrl,omitempty"`
+	CertificateURL string                `json:"certificateUrl,omitempty"`
+	SbomURL        string                `json:"sbomUrl,omitempty"`
+	Attestations   []*ReleaseAttestation `json:"attestations,omitempty"`
+}
+
+// ReleaseImage is the transformed image for the registry API.
+type ReleaseImage struct {
+	Ref          string                `json:"ref"`
+	Digest       string                `json:"digest"`
+	Platform     string                `json:"platform"`
+	Attestations []*ReleaseAttestation `json:"attestations,omitempty"`
+}
+
+// ReleaseAttestation is the registry API attestation shape.
+type ReleaseAttestation struct {
+	Type   string `json:"type"`
+	URL    string `json:"url,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// TransformAssets converts manifest assets into the registry API shape, keyed by platform.
+func TransformAssets(m *pb.Manifest) map[string]*ReleaseAsset {
+	assets := make(map[string]*ReleaseAsset)
+	for platform, asset := range m.GetAssets() {
+		assets[platform] = &ReleaseAsset{
+			Platform:       platform,
+			Filename:       asset.GetFilename(),
+			MediaType:      asset.GetMediaType(),
+			SizeBytes:      asset.GetSizeBytes(),
+			Sha256:         asset.GetSha256(),
+			DownloadURL:    asset.GetHref(),
+			SignatureURL:   asset.GetSignatureHref(),
+			CertificateURL: asset.GetCertificateHref(),
+			SbomURL:        asset.GetSbomHref(),
+			Attestations:   transformAttestations(asset.GetAttestations()),
+		}
+	}
+
+	return assets
+}
+
+// TransformImages converts manifest images into the registry API shape. Index
+// images inherit the manifest-level image attestation.
+func TransformImages(m *pb.Manifest) map[string]*ReleaseImage {
+	images := make(map[string]*ReleaseImage)
+	for platform, image := range m.GetImages() {
+		images[platform] = &ReleaseImage{
+			Ref:          image.GetRef(),
+			Digest:       image.GetDigest(),
+			Platform:     platform,
+			Attestations: transformImageAttestations(image, m.GetImageAttestation()),
+		}
+	}
+
+	return images
+}
+
+// Asset-level attestations are the source of truth in the registry. The dist
+// exporter derives the manifest-level assetAttestation summary from them.
+func transformAttestations(in []*pb.AttestationDescriptor) []*ReleaseAttestation {
+	var out []*ReleaseAttestation
+	for _, att := range in {
+		if att.GetPredicateType() == "" || att.GetBundleHref() == "" {
+			continue
+		}
+		out = append(out, &ReleaseAttestation{
+			Type: att.GetPredicateType(),
+			URL:  att.GetBundleHref(),
+		})
+	}
+	return out
+}
+
+func transformImageAttestations(image *pb.Image, att *pb.AttestationDescriptor) []*ReleaseAttestation {
+	if image == nil || !image.GetIsIndex() {
+		return nil
+	}
+	if att == nil || att.GetPredicateType() == "" {
+		return nil
+	}
+	// The registry stores image attestations per image. Dist manifests keep a
+	// single imageAttestation summary, and the exporter recreates that summary
+	// from the per-image entries.
+	//
+	// Image attestations are discovered through OCI referrers, so bundleHref is
+	// intentionally empty in current release manifests.
+	return []*ReleaseAttestation{{
+		Type: att.GetPredicateType(),
+		URL:  att.GetBundleHref(),
+	}}
+}
@@ -0,0 +1,559 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: artifacts/v1/catalog.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	_ "google.golang.org/protobuf/types/gofeaturespb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Catalog indexes every published version of one repository or of all repositories in an org.
+// A repository catalog is stored at releases/{org}/{repo}/index.json and an org catalog at releases/{org}/index.json.
+type Catalog struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Version      *string                `protobuf:"bytes,1,opt,name=version"`
+	xxx_hidden_Org          *string                `protobuf:"bytes,2,opt,name=org"`
+	xxx_hidden_Repositories *[]*CatalogRepository  `protobuf:"bytes,3,rep,name=repositories"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *Catalog) Reset() {
+	*x = Catalog{}
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Catalog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Catalog) ProtoMessage() {}
+
+func (x *Catalog) ProtoReflect() protoreflect.Message {
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Catalog) GetVersion() string {
+	if x != nil {
+		if x.xxx_hidden_Version != nil {
+			return *x.xxx_hidden_Version
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Catalog) GetOrg() string {
+	if x != nil {
+		if x.xxx_hidden_Org != nil {
+			return *x.xxx_hidden_Org
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Catalog) GetRepositories() []*CatalogRepository {
+	if x != nil {
+		if x.xxx_hidden_Repositories != nil {
+			return *x.xxx_hidden_Repositories
+		}
+	}
+	return nil
+}
+
+func (x *Catalog) SetVersion(v string) {
+	x.xxx_hidden_Version = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *Catalog) SetOrg(v string) {
+	x.xxx_hidden_Org = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *Catalog) SetRepositories(v []*CatalogRepository) {
+	x.xxx_hidden_Repositories = &v
+}
+
+func (x *Catalog) HasVersion() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *Catalog) HasOrg() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *Catalog) ClearVersion() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Version = nil
+}
+
+func (x *Catalog) ClearOrg() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Org = nil
+}
+
+type Catalog_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// version is the catalog schema version (currently "1")
+	Version *string
+	// org is the organization name (e.g., "ConductorOne")
+	Org *string
+	// repositories lists each repository in the catalog, sorted by name
+	Repositories []*CatalogRepository
+}
+
+func (b0 Catalog_builder) Build() *Catalog {
+	m0 := &Catalog{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Version != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Version = b.Version
+	}
+	if b.Org != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Org = b.Org
+	}
+	x.xxx_hidden_Repositories = &b.Repositories
+	return m0
+}
+
+// CatalogRepository lists the published versions of a single repository.
+type CatalogRepository struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name        *string                `protobuf:"bytes,1,opt,name=name"`
+	xxx_hidden_Stable      *string                `protobuf:"bytes,2,opt,name=stable"`
+	xxx_hidden_Versions    *[]*CatalogVersion     `protobuf:"bytes,3,rep,name=versions"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *CatalogRepository) Reset() {
+	*x = CatalogRepository{}
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatalogRepository) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatalogRepository) ProtoMessage() {}
+
+func (x *CatalogRepository) ProtoReflect() protoreflect.Message {
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *CatalogRepository) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *CatalogRepository) GetStable() string {
+	if x != nil {
+		if x.xxx_hidden_Stable != nil {
+			return *x.xxx_hidden_Stable
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *CatalogRepository) GetVersions() []*CatalogVersion {
+	if x != nil {
+		if x.xxx_hidden_Versions != nil {
+			return *x.xxx_hidden_Versions
+		}
+	}
+	return nil
+}
+
+func (x *CatalogRepository) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *CatalogRepository) SetStable(v string) {
+	x.xxx_hidden_Stable = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *CatalogRepository) SetVersions(v []*CatalogVersion) {
+	x.xxx_hidden_Versions = &v
+}
+
+func (x *CatalogRepository) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *CatalogRepository) HasStable() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *CatalogRepository) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *CatalogRepository) ClearStable() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Stable = nil
+}
+
+type CatalogRepository_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// name is the repository name (e.g., "baton-ukg")
+	Name *string
+	// stable is the semver of the release stable.json points to, or empty if there is no stable release
+	Stable *string
+	// versions lists every versioned manifest, sorted by ascending semver
+	Versions []*CatalogVersion
+}
+
+func (b0 CatalogRepository_builder) Build() *CatalogRepository {
+	m0 := &CatalogRepository{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.Stable != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Stable = b.Stable
+	}
+	x.xxx_hidden_Versions = &b.Versions
+	return m0
+}
+
+// CatalogVersion summarizes one versioned manifest.
+type CatalogVersion struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Semver       *string                `protobuf:"bytes,1,opt,name=semver"`
+	xxx_hidden_ReleasedAt   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=released_at,json=releasedAt"`
+	xxx_hidden_Platforms    []string               `protobuf:"bytes,3,rep,name=platforms"`
+	xxx_hidden_ImageDigests map[string]string      `protobuf:"bytes,4,rep,name=image_digests,json=imageDigests" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_ManifestHref *string                `protobuf:"bytes,5,opt,name=manifest_href,json=manifestHref"`
+	xxx_hidden_Prerelease   bool                   `protobuf:"varint,6,opt,name=prerelease"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *CatalogVersion) Reset() {
+	*x = CatalogVersion{}
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatalogVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatalogVersion) ProtoMessage() {}
+
+func (x *CatalogVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_artifacts_v1_catalog_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *CatalogVersion) GetSemver() string {
+	if x != nil {
+		if x.xxx_hidden_Semver != nil {
+			return *x.xxx_hidden_Semver
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *CatalogVersion) GetReleasedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_ReleasedAt
+	}
+	return nil
+}
+
+func (x *CatalogVersion) GetPlatforms() []string {
+	if x != nil {
+		return x.xxx_hidden_Platforms
+	}
+	return nil
+}
+
+func (x *CatalogVersion) GetImageDigests() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_ImageDigests
+	}
+	return nil
+}
+
+func (x *CatalogVersion) GetManifestHref() string {
+	if x != nil {
+		if x.xxx_hidden_ManifestHref != nil {
+			return *x.xxx_hidden_ManifestHref
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *CatalogVersion) GetPrerelease() bool {
+	if x != nil {
+		return x.xxx_hidden_Prerelease
+	}
+	return false
+}
+
+func (x *CatalogVersion) SetSemver(v string) {
+	x.xxx_hidden_Semver = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
+}
+
+func (x *CatalogVersion) SetReleasedAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_ReleasedAt = v
+}
+
+func (x *CatalogVersion) SetPlatforms(v []string) {
+	x.xxx_hidden_Platforms = v
+}
+
+func (x *CatalogVersion) SetImageDigests(v map[string]string) {
+	x.xxx_hidden_ImageDigests = v
+}
+
+func (x *CatalogVersion) SetManifestHref(v string) {
+	x.xxx_hidden_ManifestHref = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 6)
+}
+
+func (x *CatalogVersion) SetPrerelease(v bool) {
+	x.xxx_hidden_Prerelease = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 6)
+}
+
+func (x *CatalogVersion) HasSemver() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *CatalogVersion) HasReleasedAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_ReleasedAt != nil
+}
+
+func (x *CatalogVersion) HasManifestHref() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *CatalogVersion) HasPrerelease() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *CatalogVersion) ClearSemver() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Semver = nil
+}
+
+func (x *CatalogVersion) ClearReleasedAt() {
+	x.xxx_hidden_ReleasedAt = nil
+}
+
+func (x *CatalogVersion) ClearManifestHref() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_ManifestHref = nil
+}
+
+func (x *CatalogVersion) ClearPrerelease() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Prerelease = false
+}
+
+type CatalogVersion_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// semver is the semantic version tag (e.g., "v0.0.8")
+	Semver *string
+	// released_at is the timestamp when the release was created
+	ReleasedAt *timestamppb.Timestamp
+	// platforms lists the manifest's "{goos}-{goarch}" asset keys, sorted (e.g., "darwin-arm64", "linux-amd64");
+	// "checksums" and installer variants such as "windows-amd64-msi" are left out
+	Platforms []string
+	// image_digests maps image identifiers (e.g., "ecrPublic") to their "sha256:<hex>" digests
+	ImageDigests map[string]string
+	// manifest_href is the URL of the versioned manifest.json
+	ManifestHref *string
+	// prerelease is true when semver has a prerelease suffix (e.g., "v1.0.0-rc.1")
+	Prerelease *bool
+}
+
+func (b0 CatalogVersion_builder) Build() *CatalogVersion {
+	m0 := &CatalogVersion{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Semver != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
+		x.xxx_hidden_Semver = b.Semver
+	}
+	x.xxx_hidden_ReleasedAt = b.ReleasedAt
+	x.xxx_hidden_Platforms = b.Platforms
+	x.xxx_hidden_ImageDigests = b.ImageDigests
+	if b.ManifestHref != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 6)
+		x.xxx_hidden_ManifestHref = b.ManifestHref
+	}
+	if b.Prerelease != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 6)
+		x.xxx_hidden_Prerelease = *b.Prerelease
+	}
+	return m0
+}
+
+var File_artifacts_v1_catalog_proto protoreflect.FileDescriptor
+
+const file_artifacts_v1_catalog_proto_rawDesc = "" +
+	"\n" +
+	"\x1aartifacts/v1/catalog.proto\x12\fartifacts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a!google/protobuf/go_features.proto\"z\n" +
+	"\aCatalog\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x10\n" +
+	"\x03org\x18\x02 \x01(\tR\x03org\x12C\n" +
+	"\frepositories\x18\x03 \x03(\v2\x1f.artifacts.v1.CatalogRepositoryR\frepositories\"y\n" +
+	"\x11CatalogRepository\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06stable\x18\x02 \x01(\tR\x06stable\x128\n" +
+	"\bversions\x18\x03 \x03(\v2\x1c.artifacts.v1.CatalogVersionR\bversions\"\xde\x02\n" +
+	"\x0eCatalogVersion\x12\x16\n" +
+	"\x06semver\x18\x01 \x01(\tR\x06semver\x12;\n" +
+	"\vreleased_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"releasedAt\x12\x1c\n" +
+	"\tplatforms\x18\x03 \x03(\tR\tplatforms\x12S\n" +
+	"\rimage_digests\x18\x04 \x03(\v2..artifacts.v1.CatalogVersion.ImageDigestsEntryR\fimageDigests\x12#\n" +
+	"\rmanifest_href\x18\x05 \x01(\tR\fmanifestHref\x12\x1e\n" +
+	"\n" +
+	"prerelease\x18\x06 \x01(\bR\n" +
+	"prerelease\x1a?\n" +
+	"\x11ImageDigestsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01BBZ8github.com/ConductorOne/github-workflows/pb/artifacts/v1\x92\x03\x05\xd2>\x02\x10\x03b\beditionsp\xe8\a"
+
+var file_artifacts_v1_catalog_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_artifacts_v1_catalog_proto_goTypes = []any{
+	(*Catalog)(nil),               // 0: artifacts.v1.Catalog
+	(*CatalogRepository)(nil),     // 1: artifacts.v1.CatalogRepository
+	(*CatalogVersion)(nil),        // 2: artifacts.v1.CatalogVersion
+	nil,                           // 3: artifacts.v1.CatalogVersion.ImageDigestsEntry
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_artifacts_v1_catalog_proto_depIdxs = []int32{
+	1, // 0: artifacts.v1.Catalog.repositories:type_name -> artifacts.v1.CatalogRepository
+	2, // 1: artifacts.v1.CatalogRepository.versions:type_name -> artifacts.v1.CatalogVersion
+	4, // 2: artifacts.v1.CatalogVersion.released_at:type_name -> google.protobuf.Timestamp
+	3, // 3: artifacts.v1.CatalogVersion.image_digests:type_name -> artifacts.v1.CatalogVersion.ImageDigestsEntry
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_artifacts_v1_catalog_proto_init() }
+func file_artifacts_v1_catalog_proto_init() {
+	if File_artifacts_v1_catalog_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_artifacts_v1_catalog_proto_rawDesc), len(file_artifacts_v1_catalog_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_artifacts_v1_catalog_proto_goTypes,
+		DependencyIndexes: file_artifacts_v1_catalog_proto_depIdxs,
+		MessageInfos:      file_artifacts_v1_catalog_proto_msgTypes,
+	}.Build()
+	File_artifacts_v1_catalog_proto = out.File
+	file_artifacts_v1_catalog_proto_goTypes = nil
+	file_artifacts_v1_catalog_proto_depIdxs = nil
+}
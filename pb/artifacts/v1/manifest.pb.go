@@ -25,22 +25,23 @@ const (
 // Manifest represents the immutable artifact metadata for a specific release version.
 // This manifest is stored at the versioned path: releases/{org}/{repo}/{tag}/manifest.json
 type Manifest struct {
-	state                       protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Version          *string                `protobuf:"bytes,1,opt,name=version"`
-	xxx_hidden_Name             *string                `protobuf:"bytes,2,opt,name=name"`
-	xxx_hidden_Org              *string                `protobuf:"bytes,3,opt,name=org"`
-	xxx_hidden_Semver           *string                `protobuf:"bytes,4,opt,name=semver"`
-	xxx_hidden_ReleasedAt       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=released_at,json=releasedAt"`
-	xxx_hidden_Assets           map[string]*Asset      `protobuf:"bytes,6,rep,name=assets" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	xxx_hidden_Images           map[string]*Image      `protobuf:"bytes,7,rep,name=images" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	xxx_hidden_SignatureHref    *string                `protobuf:"bytes,8,opt,name=signature_href,json=signatureHref"`
-	xxx_hidden_CertificateHref  *string                `protobuf:"bytes,9,opt,name=certificate_href,json=certificateHref"`
-	xxx_hidden_ImageAttestation *AttestationDescriptor `protobuf:"bytes,10,opt,name=image_attestation,json=imageAttestation"`
-	xxx_hidden_AssetAttestation *AttestationDescriptor `protobuf:"bytes,11,opt,name=asset_attestation,json=assetAttestation"`
-	XXX_raceDetectHookData      protoimpl.RaceDetectHookData
-	XXX_presence                [1]uint32
-	unknownFields               protoimpl.UnknownFields
-	sizeCache                   protoimpl.SizeCache
+	state                        protoimpl.MessageState    `protogen:"opaque.v1"`
+	xxx_hidden_Version           *string                   `protobuf:"bytes,1,opt,name=version"`
+	xxx_hidden_Name              *string                   `protobuf:"bytes,2,opt,name=name"`
+	xxx_hidden_Org               *string                   `protobuf:"bytes,3,opt,name=org"`
+	xxx_hidden_Semver            *string                   `protobuf:"bytes,4,opt,name=semver"`
+	xxx_hidden_ReleasedAt        *timestamppb.Timestamp    `protobuf:"bytes,5,opt,name=released_at,json=releasedAt"`
+	xxx_hidden_Assets            map[string]*Asset         `protobuf:"bytes,6,rep,name=assets" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Images            map[string]*Image         `protobuf:"bytes,7,rep,name=images" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_SignatureHref     *string                   `protobuf:"bytes,8,opt,name=signature_href,json=signatureHref"`
+	xxx_hidden_CertificateHref   *string                   `protobuf:"bytes,9,opt,name=certificate_href,json=certificateHref"`
+	xxx_hidden_ImageAttestation  *AttestationDescriptor    `protobuf:"bytes,10,opt,name=image_attestation,json=imageAttestation"`
+	xxx_hidden_AssetAttestation  *AttestationDescriptor    `protobuf:"bytes,11,opt,name=asset_attestation,json=assetAttestation"`
+	xxx_hidden_AssetAttestations *[]*AttestationDescriptor `protobuf:"bytes,12,rep,name=asset_attestations,json=assetAttestations"`
+	XXX_raceDetectHookData       protoimpl.RaceDetectHookData
+	XXX_presence                 [1]uint32
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
 }
 
 func (x *Manifest) Reset() {
@@ -163,24 +164,33 @@ func (x *Manifest) GetAssetAttestation() *AttestationDescriptor {
 	return nil
 }
 
+func (x *Manifest) GetAssetAttestations() []*AttestationDescriptor {
+	if x != nil {
+		if x.xxx_hidden_AssetAttestations != nil {
+			return *x.xxx_hidden_AssetAttestations
+		}
+	}
+	return nil
+}
+
 func (x *Manifest) SetVersion(v string) {
 	x.xxx_hidden_Version = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 12)
 }
 
 func (x *Manifest) SetName(v string) {
 	x.xxx_hidden_Name = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 12)
 }
 
 func (x *Manifest) SetOrg(v string) {
 	x.xxx_hidden_Org = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 12)
 }
 
 func (x *Manifest) SetSemver(v string) {
 	x.xxx_hidden_Semver = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 12)
 }
 
 func (x *Manifest) SetReleasedAt(v *timestamppb.Timestamp) {
@@ -197,12 +207,12 @@ func (x *Manifest) SetImages(v map[string]*Image) {
 
 func (x *Manifest) SetSignatureHref(v string) {
 	x.xxx_hidden_SignatureHref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 12)
 }
 
 func (x *Manifest) SetCertificateHref(v string) {
 	x.xxx_hidden_CertificateHref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 8, 11)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 8, 12)
 }
 
 func (x *Manifest) SetImageAttestation(v *AttestationDescriptor) {
@@ -213,6 +223,10 @@ func (x *Manifest) SetAssetAttestation(v *AttestationDescriptor) {
 	x.xxx_hidden_AssetAttestation = v
 }
 
+func (x *Manifest) SetAssetAttestations(v []*AttestationDescriptor) {
+	x.xxx_hidden_AssetAttestations = &v
+}
+
 func (x *Manifest) HasVersion() bool {
 	if x == nil {
 		return false
@@ -347,8 +361,11 @@ type Manifest_builder struct {
 	ImageAttestation *AttestationDescriptor
 	// asset_attestation describes attestation conventions for S3-hosted binary artifacts.
 	// Per-asset attestations (provenance, SBOM) are in Asset.attestations[].
-	// This field documents the types used across all assets.
+	// This is the first entry of asset_attestations, kept for readers that predate that field.
 	AssetAttestation *AttestationDescriptor
+	// asset_attestations lists one descriptor per predicate type found in Asset.attestations[],
+	// sorted by predicate type. bundle_href is omitted; each asset has its own bundles.
+	AssetAttestations []*AttestationDescriptor
 }
 
 func (b0 Manifest_builder) Build() *Manifest {
@@ -356,34 +373,35 @@ func (b0 Manifest_builder) Build() *Manifest {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Version != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 12)
 		x.xxx_hidden_Version = b.Version
 	}
 	if b.Name != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 12)
 		x.xxx_hidden_Name = b.Name
 	}
 	if b.Org != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 12)
 		x.xxx_hidden_Org = b.Org
 	}
 	if b.Semver != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 12)
 		x.xxx_hidden_Semver = b.Semver
 	}
 	x.xxx_hidden_ReleasedAt = b.ReleasedAt
 	x.xxx_hidden_Assets = b.Assets
 	x.xxx_hidden_Images = b.Images
 	if b.SignatureHref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 12)
 		x.xxx_hidden_SignatureHref = b.SignatureHref
 	}
 	if b.CertificateHref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 8, 11)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 8, 12)
 		x.xxx_hidden_CertificateHref = b.CertificateHref
 	}
 	x.xxx_hidden_ImageAttestation = b.ImageAttestation
 	x.xxx_hidden_AssetAttestation = b.AssetAttestation
+	x.xxx_hidden_AssetAttestations = &b.AssetAttestations
 	return m0
 }
 
@@ -399,6 +417,11 @@ type Asset struct {
 	xxx_hidden_CertificateHref *string                   `protobuf:"bytes,7,opt,name=certificate_href,json=certificateHref"`
 	xxx_hidden_SbomHref        *string                   `protobuf:"bytes,8,opt,name=sbom_href,json=sbomHref"`
 	xxx_hidden_Attestations    *[]*AttestationDescriptor `protobuf:"bytes,9,rep,name=attestations"`
+	xxx_hidden_GoVersion       *string                   `protobuf:"bytes,10,opt,name=go_version,json=goVersion"`
+	xxx_hidden_MainModule      *GoModule                 `protobuf:"bytes,11,opt,name=main_module,json=mainModule"`
+	xxx_hidden_VcsRevision     *string                   `protobuf:"bytes,12,opt,name=vcs_revision,json=vcsRevision"`
+	xxx_hidden_BuildSettings   map[string]string         `protobuf:"bytes,13,rep,name=build_settings,json=buildSettings" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Dependencies    *[]*GoModule              `protobuf:"bytes,14,rep,name=dependencies"`
 	XXX_raceDetectHookData     protoimpl.RaceDetectHookData
 	XXX_presence               [1]uint32
 	unknownFields              protoimpl.UnknownFields
@@ -517,51 +540,116 @@ func (x *Asset) GetAttestations() []*AttestationDescriptor {
 	return nil
 }
 
+func (x *Asset) GetGoVersion() string {
+	if x != nil {
+		if x.xxx_hidden_GoVersion != nil {
+			return *x.xxx_hidden_GoVersion
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Asset) GetMainModule() *GoModule {
+	if x != nil {
+		return x.xxx_hidden_MainModule
+	}
+	return nil
+}
+
+func (x *Asset) GetVcsRevision() string {
+	if x != nil {
+		if x.xxx_hidden_VcsRevision != nil {
+			return *x.xxx_hidden_VcsRevision
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Asset) GetBuildSettings() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_BuildSettings
+	}
+	return nil
+}
+
+func (x *Asset) GetDependencies() []*GoModule {
+	if x != nil {
+		if x.xxx_hidden_Dependencies != nil {
+			return *x.xxx_hidden_Dependencies
+		}
+	}
+	return nil
+}
+
 func (x *Asset) SetFilename(v string) {
 	x.xxx_hidden_Filename = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 14)
 }
 
 func (x *Asset) SetMediaType(v string) {
 	x.xxx_hidden_MediaType = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 14)
 }
 
 func (x *Asset) SetSizeBytes(v int64) {
 	x.xxx_hidden_SizeBytes = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 14)
 }
 
 func (x *Asset) SetSha256(v string) {
 	x.xxx_hidden_Sha256 = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 14)
 }
 
 func (x *Asset) SetHref(v string) {
 	x.xxx_hidden_Href = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 14)
 }
 
 func (x *Asset) SetSignatureHref(v string) {
 	x.xxx_hidden_SignatureHref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 14)
 }
 
 func (x *Asset) SetCertificateHref(v string) {
 	x.xxx_hidden_CertificateHref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 14)
 }
 
 // Deprecated: Marked as deprecated in artifacts/v1/manifest.proto.
 func (x *Asset) SetSbomHref(v string) {
 	x.xxx_hidden_SbomHref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 9)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 14)
 }
 
 func (x *Asset) SetAttestations(v []*AttestationDescriptor) {
 	x.xxx_hidden_Attestations = &v
 }
 
+func (x *Asset) SetGoVersion(v string) {
+	x.xxx_hidden_GoVersion = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 9, 14)
+}
+
+func (x *Asset) SetMainModule(v *GoModule) {
+	x.xxx_hidden_MainModule = v
+}
+
+func (x *Asset) SetVcsRevision(v string) {
+	x.xxx_hidden_VcsRevision = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 11, 14)
+}
+
+func (x *Asset) SetBuildSettings(v map[string]string) {
+	x.xxx_hidden_BuildSettings = v
+}
+
+func (x *Asset) SetDependencies(v []*GoModule) {
+	x.xxx_hidden_Dependencies = &v
+}
+
 func (x *Asset) HasFilename() bool {
 	if x == nil {
 		return false
@@ -619,6 +707,27 @@ func (x *Asset) HasSbomHref() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
 }
 
+func (x *Asset) HasGoVersion() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 9)
+}
+
+func (x *Asset) HasMainModule() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_MainModule != nil
+}
+
+func (x *Asset) HasVcsRevision() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 11)
+}
+
 func (x *Asset) ClearFilename() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Filename = nil
@@ -660,6 +769,20 @@ func (x *Asset) ClearSbomHref() {
 	x.xxx_hidden_SbomHref = nil
 }
 
+func (x *Asset) ClearGoVersion() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 9)
+	x.xxx_hidden_GoVersion = nil
+}
+
+func (x *Asset) ClearMainModule() {
+	x.xxx_hidden_MainModule = nil
+}
+
+func (x *Asset) ClearVcsRevision() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 11)
+	x.xxx_hidden_VcsRevision = nil
+}
+
 type Asset_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -686,6 +809,18 @@ type Asset_builder struct {
 	// For your customer flow (verify one OS artifact at a time), this is the recommended place to link
 	// per-artifact provenance and/or SBOM attestations stored in S3.
 	Attestations []*AttestationDescriptor
+	// go_version is the Go toolchain that built the binary in the archive (e.g., "go1.25.2").
+	// Build info fields are empty for non-archive assets and archives without a Go binary.
+	GoVersion *string
+	// main_module is the main module of the binary, with its version (e.g., "v0.0.8" or "(devel)").
+	MainModule *GoModule
+	// vcs_revision is the vcs.revision build setting (the commit the binary was built from).
+	VcsRevision *string
+	// build_settings are the binary's build settings keyed by name
+	// (e.g., "CGO_ENABLED", "-trimpath", "GOOS", "vcs.modified").
+	BuildSettings map[string]string
+	// dependencies lists the modules linked into the binary, in build info order.
+	Dependencies []*GoModule
 }
 
 func (b0 Asset_builder) Build() *Asset {
@@ -693,38 +828,223 @@ func (b0 Asset_builder) Build() *Asset {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Filename != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 14)
 		x.xxx_hidden_Filename = b.Filename
 	}
 	if b.MediaType != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 14)
 		x.xxx_hidden_MediaType = b.MediaType
 	}
 	if b.SizeBytes != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 14)
 		x.xxx_hidden_SizeBytes = *b.SizeBytes
 	}
 	if b.Sha256 != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 14)
 		x.xxx_hidden_Sha256 = b.Sha256
 	}
 	if b.Href != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 14)
 		x.xxx_hidden_Href = b.Href
 	}
 	if b.SignatureHref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 14)
 		x.xxx_hidden_SignatureHref = b.SignatureHref
 	}
 	if b.CertificateHref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 14)
 		x.xxx_hidden_CertificateHref = b.CertificateHref
 	}
 	if b.SbomHref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 9)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 14)
 		x.xxx_hidden_SbomHref = b.SbomHref
 	}
 	x.xxx_hidden_Attestations = &b.Attestations
+	if b.GoVersion != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 9, 14)
+		x.xxx_hidden_GoVersion = b.GoVersion
+	}
+	x.xxx_hidden_MainModule = b.MainModule
+	if b.VcsRevision != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 11, 14)
+		x.xxx_hidden_VcsRevision = b.VcsRevision
+	}
+	x.xxx_hidden_BuildSettings = b.BuildSettings
+	x.xxx_hidden_Dependencies = &b.Dependencies
+	return m0
+}
+
+// GoModule identifies a Go module recorded in a binary's build info.
+type GoModule struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Path        *string                `protobuf:"bytes,1,opt,name=path"`
+	xxx_hidden_Version     *string                `protobuf:"bytes,2,opt,name=version"`
+	xxx_hidden_Sum         *string                `protobuf:"bytes,3,opt,name=sum"`
+	xxx_hidden_Replace     *GoModule              `protobuf:"bytes,4,opt,name=replace"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GoModule) Reset() {
+	*x = GoModule{}
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoModule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoModule) ProtoMessage() {}
+
+func (x *GoModule) ProtoReflect() protoreflect.Message {
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GoModule) GetPath() string {
+	if x != nil {
+		if x.xxx_hidden_Path != nil {
+			return *x.xxx_hidden_Path
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GoModule) GetVersion() string {
+	if x != nil {
+		if x.xxx_hidden_Version != nil {
+			return *x.xxx_hidden_Version
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GoModule) GetSum() string {
+	if x != nil {
+		if x.xxx_hidden_Sum != nil {
+			return *x.xxx_hidden_Sum
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GoModule) GetReplace() *GoModule {
+	if x != nil {
+		return x.xxx_hidden_Replace
+	}
+	return nil
+}
+
+func (x *GoModule) SetPath(v string) {
+	x.xxx_hidden_Path = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *GoModule) SetVersion(v string) {
+	x.xxx_hidden_Version = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *GoModule) SetSum(v string) {
+	x.xxx_hidden_Sum = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *GoModule) SetReplace(v *GoModule) {
+	x.xxx_hidden_Replace = v
+}
+
+func (x *GoModule) HasPath() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *GoModule) HasVersion() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *GoModule) HasSum() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *GoModule) HasReplace() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Replace != nil
+}
+
+func (x *GoModule) ClearPath() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Path = nil
+}
+
+func (x *GoModule) ClearVersion() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Version = nil
+}
+
+func (x *GoModule) ClearSum() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Sum = nil
+}
+
+func (x *GoModule) ClearReplace() {
+	x.xxx_hidden_Replace = nil
+}
+
+type GoModule_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// path is the module path (e.g., "github.com/conductorone/baton-sdk")
+	Path *string
+	// version is the module version (e.g., "v0.3.35")
+	Version *string
+	// sum is the go.sum checksum (e.g., "h1:..."); empty for the main module
+	Sum *string
+	// replace is the module this one was replaced with, if any
+	Replace *GoModule
+}
+
+func (b0 GoModule_builder) Build() *GoModule {
+	m0 := &GoModule{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Path != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Path = b.Path
+	}
+	if b.Version != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Version = b.Version
+	}
+	if b.Sum != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Sum = b.Sum
+	}
+	x.xxx_hidden_Replace = b.Replace
 	return m0
 }
 
@@ -736,6 +1056,9 @@ type Image struct {
 	xxx_hidden_Tag         *string                `protobuf:"bytes,3,opt,name=tag"`
 	xxx_hidden_Uri         *string                `protobuf:"bytes,4,opt,name=uri"`
 	xxx_hidden_IsIndex     bool                   `protobuf:"varint,5,opt,name=is_index,json=isIndex"`
+	xxx_hidden_Platforms   *[]*ImagePlatform      `protobuf:"bytes,6,rep,name=platforms"`
+	xxx_hidden_Revision    *string                `protobuf:"bytes,7,opt,name=revision"`
+	xxx_hidden_Source      *string                `protobuf:"bytes,8,opt,name=source"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
@@ -744,7 +1067,7 @@ type Image struct {
 
 func (x *Image) Reset() {
 	*x = Image{}
-	mi := &file_artifacts_v1_manifest_proto_msgTypes[2]
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -756,7 +1079,7 @@ func (x *Image) String() string {
 func (*Image) ProtoMessage() {}
 
 func (x *Image) ProtoReflect() protoreflect.Message {
-	mi := &file_artifacts_v1_manifest_proto_msgTypes[2]
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -814,29 +1137,72 @@ func (x *Image) GetIsIndex() bool {
 	return false
 }
 
+func (x *Image) GetPlatforms() []*ImagePlatform {
+	if x != nil {
+		if x.xxx_hidden_Platforms != nil {
+			return *x.xxx_hidden_Platforms
+		}
+	}
+	return nil
+}
+
+func (x *Image) GetRevision() string {
+	if x != nil {
+		if x.xxx_hidden_Revision != nil {
+			return *x.xxx_hidden_Revision
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Image) GetSource() string {
+	if x != nil {
+		if x.xxx_hidden_Source != nil {
+			return *x.xxx_hidden_Source
+		}
+		return ""
+	}
+	return ""
+}
+
 func (x *Image) SetRef(v string) {
 	x.xxx_hidden_Ref = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 8)
 }
 
 func (x *Image) SetDigest(v string) {
 	x.xxx_hidden_Digest = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 8)
 }
 
 func (x *Image) SetTag(v string) {
 	x.xxx_hidden_Tag = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 8)
 }
 
 func (x *Image) SetUri(v string) {
 	x.xxx_hidden_Uri = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 8)
 }
 
 func (x *Image) SetIsIndex(v bool) {
 	x.xxx_hidden_IsIndex = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 5)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 8)
+}
+
+func (x *Image) SetPlatforms(v []*ImagePlatform) {
+	x.xxx_hidden_Platforms = &v
+}
+
+func (x *Image) SetRevision(v string) {
+	x.xxx_hidden_Revision = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 8)
+}
+
+func (x *Image) SetSource(v string) {
+	x.xxx_hidden_Source = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 8)
 }
 
 func (x *Image) HasRef() bool {
@@ -874,6 +1240,20 @@ func (x *Image) HasIsIndex() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
 }
 
+func (x *Image) HasRevision() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *Image) HasSource() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
 func (x *Image) ClearRef() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Ref = nil
@@ -899,6 +1279,16 @@ func (x *Image) ClearIsIndex() {
 	x.xxx_hidden_IsIndex = false
 }
 
+func (x *Image) ClearRevision() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_Revision = nil
+}
+
+func (x *Image) ClearSource() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_Source = nil
+}
+
 type Image_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -912,6 +1302,14 @@ type Image_builder struct {
 	Uri *string
 	// is_index indicates whether this digest refers to a multi-arch index (manifest list).
 	IsIndex *bool
+	// platforms lists the per-platform child manifests of an index, sorted by os, architecture
+	// and variant. It is empty for single-platform images.
+	Platforms []*ImagePlatform
+	// revision is the source commit the image was built from (org.opencontainers.image.revision),
+	// when the image records it.
+	Revision *string
+	// source is the source repository URL (org.opencontainers.image.source), when the image records it.
+	Source *string
 }
 
 func (b0 Image_builder) Build() *Image {
@@ -919,25 +1317,216 @@ func (b0 Image_builder) Build() *Image {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Ref != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 8)
 		x.xxx_hidden_Ref = b.Ref
 	}
 	if b.Digest != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 8)
 		x.xxx_hidden_Digest = b.Digest
 	}
 	if b.Tag != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 8)
 		x.xxx_hidden_Tag = b.Tag
 	}
 	if b.Uri != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 8)
 		x.xxx_hidden_Uri = b.Uri
 	}
 	if b.IsIndex != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 5)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 8)
 		x.xxx_hidden_IsIndex = *b.IsIndex
 	}
+	x.xxx_hidden_Platforms = &b.Platforms
+	if b.Revision != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 8)
+		x.xxx_hidden_Revision = b.Revision
+	}
+	if b.Source != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 8)
+		x.xxx_hidden_Source = b.Source
+	}
+	return m0
+}
+
+// ImagePlatform describes one platform-specific manifest of a multi-arch image index.
+type ImagePlatform struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Os           *string                `protobuf:"bytes,1,opt,name=os"`
+	xxx_hidden_Architecture *string                `protobuf:"bytes,2,opt,name=architecture"`
+	xxx_hidden_Variant      *string                `protobuf:"bytes,3,opt,name=variant"`
+	xxx_hidden_Digest       *string                `protobuf:"bytes,4,opt,name=digest"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *ImagePlatform) Reset() {
+	*x = ImagePlatform{}
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImagePlatform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImagePlatform) ProtoMessage() {}
+
+func (x *ImagePlatform) ProtoReflect() protoreflect.Message {
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ImagePlatform) GetOs() string {
+	if x != nil {
+		if x.xxx_hidden_Os != nil {
+			return *x.xxx_hidden_Os
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ImagePlatform) GetArchitecture() string {
+	if x != nil {
+		if x.xxx_hidden_Architecture != nil {
+			return *x.xxx_hidden_Architecture
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ImagePlatform) GetVariant() string {
+	if x != nil {
+		if x.xxx_hidden_Variant != nil {
+			return *x.xxx_hidden_Variant
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ImagePlatform) GetDigest() string {
+	if x != nil {
+		if x.xxx_hidden_Digest != nil {
+			return *x.xxx_hidden_Digest
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ImagePlatform) SetOs(v string) {
+	x.xxx_hidden_Os = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *ImagePlatform) SetArchitecture(v string) {
+	x.xxx_hidden_Architecture = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *ImagePlatform) SetVariant(v string) {
+	x.xxx_hidden_Variant = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *ImagePlatform) SetDigest(v string) {
+	x.xxx_hidden_Digest = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *ImagePlatform) HasOs() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ImagePlatform) HasArchitecture() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ImagePlatform) HasVariant() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *ImagePlatform) HasDigest() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *ImagePlatform) ClearOs() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Os = nil
+}
+
+func (x *ImagePlatform) ClearArchitecture() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Architecture = nil
+}
+
+func (x *ImagePlatform) ClearVariant() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Variant = nil
+}
+
+func (x *ImagePlatform) ClearDigest() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Digest = nil
+}
+
+type ImagePlatform_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// os is the platform operating system (e.g., "linux").
+	Os *string
+	// architecture is the platform CPU architecture (e.g., "amd64", "arm64").
+	Architecture *string
+	// variant is the CPU variant (e.g., "v7" for arm), or empty.
+	Variant *string
+	// digest is the platform manifest digest in the format "sha256:<hex>".
+	Digest *string
+}
+
+func (b0 ImagePlatform_builder) Build() *ImagePlatform {
+	m0 := &ImagePlatform{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Os != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Os = b.Os
+	}
+	if b.Architecture != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Architecture = b.Architecture
+	}
+	if b.Variant != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Variant = b.Variant
+	}
+	if b.Digest != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Digest = b.Digest
+	}
 	return m0
 }
 
@@ -961,7 +1550,7 @@ type AttestationDescriptor struct {
 
 func (x *AttestationDescriptor) Reset() {
 	*x = AttestationDescriptor{}
-	mi := &file_artifacts_v1_manifest_proto_msgTypes[3]
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -973,7 +1562,7 @@ func (x *AttestationDescriptor) String() string {
 func (*AttestationDescriptor) ProtoMessage() {}
 
 func (x *AttestationDescriptor) ProtoReflect() protoreflect.Message {
-	mi := &file_artifacts_v1_manifest_proto_msgTypes[3]
+	mi := &file_artifacts_v1_manifest_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1103,7 +1692,7 @@ var File_artifacts_v1_manifest_proto protoreflect.FileDescriptor
 
 const file_artifacts_v1_manifest_proto_rawDesc = "" +
 	"\n" +
-	"\x1bartifacts/v1/manifest.proto\x12\fartifacts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a!google/protobuf/go_features.proto\"\xad\x05\n" +
+	"\x1bartifacts/v1/manifest.proto\x12\fartifacts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a!google/protobuf/go_features.proto\"\x81\x06\n" +
 	"\bManifest\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\tR\aversion\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
@@ -1117,13 +1706,14 @@ const file_artifacts_v1_manifest_proto_rawDesc = "" +
 	"\x10certificate_href\x18\t \x01(\tR\x0fcertificateHref\x12P\n" +
 	"\x11image_attestation\x18\n" +
 	" \x01(\v2#.artifacts.v1.AttestationDescriptorR\x10imageAttestation\x12P\n" +
-	"\x11asset_attestation\x18\v \x01(\v2#.artifacts.v1.AttestationDescriptorR\x10assetAttestation\x1aN\n" +
+	"\x11asset_attestation\x18\v \x01(\v2#.artifacts.v1.AttestationDescriptorR\x10assetAttestation\x12R\n" +
+	"\x12asset_attestations\x18\f \x03(\v2#.artifacts.v1.AttestationDescriptorR\x11assetAttestations\x1aN\n" +
 	"\vAssetsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
 	"\x05value\x18\x02 \x01(\v2\x13.artifacts.v1.AssetR\x05value:\x028\x01\x1aN\n" +
 	"\vImagesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
-	"\x05value\x18\x02 \x01(\v2\x13.artifacts.v1.ImageR\x05value:\x028\x01\"\xc9\x02\n" +
+	"\x05value\x18\x02 \x01(\v2\x13.artifacts.v1.ImageR\x05value:\x028\x01\"\x91\x05\n" +
 	"\x05Asset\x12\x1a\n" +
 	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x1d\n" +
 	"\n" +
@@ -1135,43 +1725,76 @@ const file_artifacts_v1_manifest_proto_rawDesc = "" +
 	"\x0esignature_href\x18\x06 \x01(\tR\rsignatureHref\x12)\n" +
 	"\x10certificate_href\x18\a \x01(\tR\x0fcertificateHref\x12\x1f\n" +
 	"\tsbom_href\x18\b \x01(\tB\x02\x18\x01R\bsbomHref\x12G\n" +
-	"\fattestations\x18\t \x03(\v2#.artifacts.v1.AttestationDescriptorR\fattestations\"p\n" +
+	"\fattestations\x18\t \x03(\v2#.artifacts.v1.AttestationDescriptorR\fattestations\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\n" +
+	" \x01(\tR\tgoVersion\x127\n" +
+	"\vmain_module\x18\v \x01(\v2\x16.artifacts.v1.GoModuleR\n" +
+	"mainModule\x12!\n" +
+	"\fvcs_revision\x18\f \x01(\tR\vvcsRevision\x12M\n" +
+	"\x0ebuild_settings\x18\r \x03(\v2&.artifacts.v1.Asset.BuildSettingsEntryR\rbuildSettings\x12:\n" +
+	"\fdependencies\x18\x0e \x03(\v2\x16.artifacts.v1.GoModuleR\fdependencies\x1a@\n" +
+	"\x12BuildSettingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"|\n" +
+	"\bGoModule\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\tR\x03sum\x120\n" +
+	"\areplace\x18\x04 \x01(\v2\x16.artifacts.v1.GoModuleR\areplace\"\xdf\x01\n" +
 	"\x05Image\x12\x10\n" +
 	"\x03ref\x18\x01 \x01(\tR\x03ref\x12\x16\n" +
 	"\x06digest\x18\x02 \x01(\tR\x06digest\x12\x10\n" +
 	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x10\n" +
 	"\x03uri\x18\x04 \x01(\tR\x03uri\x12\x19\n" +
-	"\bis_index\x18\x05 \x01(\bR\aisIndex\"\x8a\x01\n" +
+	"\bis_index\x18\x05 \x01(\bR\aisIndex\x129\n" +
+	"\tplatforms\x18\x06 \x03(\v2\x1b.artifacts.v1.ImagePlatformR\tplatforms\x12\x1a\n" +
+	"\brevision\x18\a \x01(\tR\brevision\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\"u\n" +
+	"\rImagePlatform\x12\x0e\n" +
+	"\x02os\x18\x01 \x01(\tR\x02os\x12\"\n" +
+	"\farchitecture\x18\x02 \x01(\tR\farchitecture\x12\x18\n" +
+	"\avariant\x18\x03 \x01(\tR\avariant\x12\x16\n" +
+	"\x06digest\x18\x04 \x01(\tR\x06digest\"\x8a\x01\n" +
 	"\x15AttestationDescriptor\x12)\n" +
 	"\x10attestation_type\x18\x01 \x01(\tR\x0fattestationType\x12%\n" +
 	"\x0epredicate_type\x18\x02 \x01(\tR\rpredicateType\x12\x1f\n" +
 	"\vbundle_href\x18\x03 \x01(\tR\n" +
 	"bundleHrefBBZ8github.com/ConductorOne/github-workflows/pb/artifacts/v1\x92\x03\x05\xd2>\x02\x10\x03b\beditionsp\xe8\a"
 
-var file_artifacts_v1_manifest_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_artifacts_v1_manifest_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_artifacts_v1_manifest_proto_goTypes = []any{
 	(*Manifest)(nil),              // 0: artifacts.v1.Manifest
 	(*Asset)(nil),                 // 1: artifacts.v1.Asset
-	(*Image)(nil),                 // 2: artifacts.v1.Image
-	(*AttestationDescriptor)(nil), // 3: artifacts.v1.AttestationDescriptor
-	nil,                           // 4: artifacts.v1.Manifest.AssetsEntry
-	nil,                           // 5: artifacts.v1.Manifest.ImagesEntry
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(*GoModule)(nil),              // 2: artifacts.v1.GoModule
+	(*Image)(nil),                 // 3: artifacts.v1.Image
+	(*ImagePlatform)(nil),         // 4: artifacts.v1.ImagePlatform
+	(*AttestationDescriptor)(nil), // 5: artifacts.v1.AttestationDescriptor
+	nil,                           // 6: artifacts.v1.Manifest.AssetsEntry
+	nil,                           // 7: artifacts.v1.Manifest.ImagesEntry
+	nil,                           // 8: artifacts.v1.Asset.BuildSettingsEntry
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
 }
 var file_artifacts_v1_manifest_proto_depIdxs = []int32{
-	6, // 0: artifacts.v1.Manifest.released_at:type_name -> google.protobuf.Timestamp
-	4, // 1: artifacts.v1.Manifest.assets:type_name -> artifacts.v1.Manifest.AssetsEntry
-	5, // 2: artifacts.v1.Manifest.images:type_name -> artifacts.v1.Manifest.ImagesEntry
-	3, // 3: artifacts.v1.Manifest.image_attestation:type_name -> artifacts.v1.AttestationDescriptor
-	3, // 4: artifacts.v1.Manifest.asset_attestation:type_name -> artifacts.v1.AttestationDescriptor
-	3, // 5: artifacts.v1.Asset.attestations:type_name -> artifacts.v1.AttestationDescriptor
-	1, // 6: artifacts.v1.Manifest.AssetsEntry.value:type_name -> artifacts.v1.Asset
-	2, // 7: artifacts.v1.Manifest.ImagesEntry.value:type_name -> artifacts.v1.Image
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	9,  // 0: artifacts.v1.Manifest.released_at:type_name -> google.protobuf.Timestamp
+	6,  // 1: artifacts.v1.Manifest.assets:type_name -> artifacts.v1.Manifest.AssetsEntry
+	7,  // 2: artifacts.v1.Manifest.images:type_name -> artifacts.v1.Manifest.ImagesEntry
+	5,  // 3: artifacts.v1.Manifest.image_attestation:type_name -> artifacts.v1.AttestationDescriptor
+	5,  // 4: artifacts.v1.Manifest.asset_attestation:type_name -> artifacts.v1.AttestationDescriptor
+	5,  // 5: artifacts.v1.Manifest.asset_attestations:type_name -> artifacts.v1.AttestationDescriptor
+	5,  // 6: artifacts.v1.Asset.attestations:type_name -> artifacts.v1.AttestationDescriptor
+	2,  // 7: artifacts.v1.Asset.main_module:type_name -> artifacts.v1.GoModule
+	8,  // 8: artifacts.v1.Asset.build_settings:type_name -> artifacts.v1.Asset.BuildSettingsEntry
+	2,  // 9: artifacts.v1.Asset.dependencies:type_name -> artifacts.v1.GoModule
+	2,  // 10: artifacts.v1.GoModule.replace:type_name -> artifacts.v1.GoModule
+	4,  // 11: artifacts.v1.Image.platforms:type_name -> artifacts.v1.ImagePlatform
+	1,  // 12: artifacts.v1.Manifest.AssetsEntry.value:type_name -> artifacts.v1.Asset
+	3,  // 13: artifacts.v1.Manifest.ImagesEntry.value:type_name -> artifacts.v1.Image
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_artifacts_v1_manifest_proto_init() }
@@ -1185,7 +1808,7 @@ func file_artifacts_v1_manifest_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_artifacts_v1_manifest_proto_rawDesc), len(file_artifacts_v1_manifest_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
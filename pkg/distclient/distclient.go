@@ -0,0 +1,303 @@
+// Package distclient resolves, downloads and unpacks connector releases
+// published to the dist bucket.
+//
+// A release is located by org, repo and version ("stable" or a tag such as
+// "v0.1.98"). The asset for a platform is chosen from the release manifest,
+// downloaded into a cache keyed by its sha256, verified and unpacked:
+//
+//	c := &distclient.Client{}
+//	res, err := c.Fetch(ctx, "ConductorOne", "baton-github", "stable", runtime.GOOS, runtime.GOARCH, "bin")
+package distclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const (
+	// DefaultBaseURL is the public CDN prefix for releases.
+	DefaultBaseURL = "https://dist.conductorone.com/releases"
+	// Stable selects the release stable.json points to.
+	Stable = "stable"
+)
+
+var (
+	// ErrNoAsset is returned when a manifest has no asset for the requested platform.
+	ErrNoAsset = errors.New("no asset for platform")
+	// ErrChecksumMismatch is returned when a downloaded asset does not match the manifest.
+	ErrChecksumMismatch = errors.New("asset checksum mismatch")
+)
+
+// Client fetches releases. The zero value uses DefaultBaseURL,
+// http.DefaultClient and a cache under os.UserCacheDir.
+type Client struct {
+	// BaseURL is the releases prefix; manifests are read from
+	// {BaseURL}/{org}/{repo}/{version}/manifest.json.
+	BaseURL string
+	// HTTPClient sends every request; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// CacheDir holds downloaded assets as {CacheDir}/{sha256}/{filename}.
+	CacheDir string
+}
+
+// Result describes a fetched release asset.
+type Result struct {
+	Manifest *pb.Manifest
+	// Key is the manifest asset key, e.g. "linux-amd64".
+	Key   string
+	Asset *pb.Asset
+	// ArchivePath is the verified archive in the cache.
+	ArchivePath string
+	// Dir is where the archive was unpacked.
+	Dir string
+}
+
+// Fetch resolves version, selects the asset for goos/goarch, downloads and
+// verifies it, and unpacks it into dir.
+func (c *Client) Fetch(ctx context.Context, org, repo, version, goos, goarch, dir string) (*Result, error) {
+	m, err := c.Manifest(ctx, org, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	key, asset, err := SelectAsset(m, goos, goarch)
+	if err != nil {
+		return nil, err
+	}
+	path, err := c.Download(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	if err := Unpack(path, dir); err != nil {
+		return nil, err
+	}
+	return &Result{Manifest: m, Key: key, Asset: asset, ArchivePath: path, Dir: dir}, nil
+}
+
+// Stable fetches {org}/{repo}/stable.json.
+func (c *Client) Stable(ctx context.Context, org, repo string) (*pb.Stable, error) {
+	stable := &pb.Stable{}
+	if err := c.getDocument(ctx, org+"/"+repo+"/stable.json", stable); err != nil {
+		return nil, err
+	}
+	if !stable.HasManifest() {
+		return nil, fmt.Errorf("%s/%s stable.json has no manifest", org, repo)
+	}
+	return stable, nil
+}
+
+// Manifest returns the manifest for version, which is either Stable or a tag.
+func (c *Client) Manifest(ctx context.Context, org, repo, version string) (*pb.Manifest, error) {
+	if version == Stable {
+		stable, err := c.Stable(ctx, org, repo)
+		if err != nil {
+			return nil, err
+		}
+		return stable.GetManifest(), nil
+	}
+	m := &pb.Manifest{}
+	if err := c.getDocument(ctx, org+"/"+repo+"/"+version+"/manifest.json", m); err != nil {
+		return nil, err
+	}
+	if m.GetSemver() != version {
+		return nil, fmt.Errorf("manifest semver %q does not match requested version %q", m.GetSemver(), version)
+	}
+	return m, nil
+}
+
+// SelectAsset returns the archive asset for goos/goarch. Manifest keys are
+// "{goos}-{goarch}"; installer variants such as "windows-amd64-msi" are never
+// selected.
+func SelectAsset(m *pb.Manifest, goos, goarch string) (string, *pb.Asset, error) {
+	key := goos + "-" + goarch
+	if asset, ok := m.GetAssets()[key]; ok {
+		return key, asset, nil
+	}
+	available := make([]string, 0, len(m.GetAssets()))
+	for k := range m.GetAssets() {
+		available = append(available, k)
+	}
+	sort.Strings(available)
+	return "", nil, fmt.Errorf("%w %s in %s %s (available: %s)", ErrNoAsset, key, m.GetName(), m.GetSemver(), strings.Join(available, ", "))
+}
+
+// Download returns the cached path of asset, downloading it first if needed.
+// An interrupted download is resumed from its partial file with a Range
+// request. The file is only moved into place once its size and sha256 match
+// the manifest.
+func (c *Client) Download(ctx context.Context, asset *pb.Asset) (string, error) {
+	sha := strings.ToLower(asset.GetSha256())
+	if sha == "" || asset.GetFilename() == "" || strings.ContainsAny(asset.GetFilename(), `/\`) {
+		return "", fmt.Errorf("asset %q has no usable filename or sha256", asset.GetFilename())
+	}
+	cacheDir, err := c.cacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, sha)
+	path := filepath.Join(dir, asset.GetFilename())
+
+	if err := verifyFile(path, asset); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	partial := path + ".partial"
+	if err := c.downloadTo(ctx, asset.GetHref(), partial); err != nil {
+		return "", err
+	}
+	if err := verifyFile(partial, asset); err != nil {
+		// A corrupt partial file must not be resumed.
+		os.Remove(partial)
+		return "", err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return "", fmt.Errorf("moving download into cache: %w", err)
+	}
+	return path, nil
+}
+
+// downloadTo appends the remainder of href to path, restarting from scratch
+// when the server ignores the Range header.
+func (c *Client) downloadTo(ctx context.Context, href, path string) error {
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
+	if err != nil {
+		return fmt.Errorf("creating request for %s: %w", href, err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", href, err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is already complete (or longer than the asset);
+		// leave it for verification.
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("GET %s: HTTP %d", href, resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("downloading %s: %w", href, err)
+	}
+	return f.Close()
+}
+
+// verifyFile checks path against the asset's size and sha256.
+func verifyFile(path string, asset *pb.Asset) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if asset.GetSizeBytes() > 0 && info.Size() != asset.GetSizeBytes() {
+		return fmt.Errorf("%w: %s is %d bytes, manifest says %d", ErrChecksumMismatch, asset.GetFilename(), info.Size(), asset.GetSizeBytes())
+	}
+	sum, err := manifest.SHA256File(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, asset.GetSha256()) {
+		return fmt.Errorf("%w: %s has sha256 %s, manifest says %s", ErrChecksumMismatch, asset.GetFilename(), sum, asset.GetSha256())
+	}
+	return nil
+}
+
+// Bundle downloads the Sigstore bundle referenced by descriptor.
+func (c *Client) Bundle(ctx context.Context, descriptor *pb.AttestationDescriptor) ([]byte, error) {
+	if descriptor.GetBundleHref() == "" {
+		return nil, fmt.Errorf("attestation %s has no bundle_href", descriptor.GetPredicateType())
+	}
+	return c.get(ctx, descriptor.GetBundleHref())
+}
+
+func (c *Client) getDocument(ctx context.Context, path string, m proto.Message) error {
+	href := manifest.JoinURL(c.baseURL(), path)
+	data, err := c.get(ctx, href)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("parsing %s: %w", href, err)
+	}
+	return nil
+}
+
+// get reads the body of href, which must respond with 200 OK.
+func (c *Client) get(ctx context.Context, href string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", href, err)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", href, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", href, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", href, err)
+	}
+	return data, nil
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return c.BaseURL
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+func (c *Client) cacheDir() (string, error) {
+	if c.CacheDir != "" {
+		return c.CacheDir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating cache directory: %w", err)
+	}
+	return filepath.Join(dir, "conductorone", "dist"), nil
+}
@@ -0,0 +1,284 @@
+package distclient
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+const binaryContent = "#!/bin/sh\necho baton\n"
+
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// distServer serves a stable.json, one versioned manifest and its linux-amd64
+// archive, and records the Range header of each archive request.
+type distServer struct {
+	*httptest.Server
+	archive []byte
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newDistServer(t *testing.T, sha string) *distServer {
+	t.Helper()
+	s := &distServer{archive: tarGz(t, "baton-example", binaryContent)}
+	if sha == "" {
+		sum := sha256.Sum256(s.archive)
+		sha = hex.EncodeToString(sum[:])
+	}
+
+	mux := http.NewServeMux()
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	size := int64(len(s.archive))
+	m := pb.Manifest_builder{
+		Version: strPtr(manifest.SchemaVersion),
+		Org:     strPtr("ConductorOne"),
+		Name:    strPtr("baton-example"),
+		Semver:  strPtr("v1.0.0"),
+		Assets: map[string]*pb.Asset{
+			"linux-amd64": pb.Asset_builder{
+				Filename:  strPtr("baton-example-v1.0.0-linux-amd64.tar.gz"),
+				SizeBytes: &size,
+				Sha256:    strPtr(sha),
+				Href:      strPtr(s.URL + "/ConductorOne/baton-example/v1.0.0/baton-example-v1.0.0-linux-amd64.tar.gz"),
+			}.Build(),
+		},
+	}.Build()
+	manifestJSON, err := manifest.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stableJSON, err := manifest.Marshal(pb.Stable_builder{Version: strPtr(manifest.StableSchemaVersion), Manifest: m}.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux.HandleFunc("/ConductorOne/baton-example/stable.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(stableJSON)
+	})
+	mux.HandleFunc("/ConductorOne/baton-example/v1.0.0/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(manifestJSON)
+	})
+	mux.HandleFunc("/ConductorOne/baton-example/v1.0.0/baton-example-v1.0.0-linux-amd64.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		s.mu.Unlock()
+		http.ServeContent(w, r, "archive.tar.gz", time.Time{}, bytes.NewReader(s.archive))
+	})
+	return s
+}
+
+func (s *distServer) archiveRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+func TestFetchStableUnpacksAndCaches(t *testing.T) {
+	srv := newDistServer(t, "")
+	c := &Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: t.TempDir()}
+
+	dir := t.TempDir()
+	res, err := c.Fetch(context.Background(), "ConductorOne", "baton-example", Stable, "linux", "amd64", dir)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if res.Key != "linux-amd64" || res.Manifest.GetSemver() != "v1.0.0" {
+		t.Fatalf("result = %s %s", res.Key, res.Manifest.GetSemver())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "baton-example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != binaryContent {
+		t.Fatalf("unpacked content = %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "baton-example")); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("unpacked binary is not executable: %v %v", info.Mode(), err)
+	}
+
+	// A second fetch of a pinned version is served from the cache.
+	if _, err := c.Fetch(context.Background(), "ConductorOne", "baton-example", "v1.0.0", "linux", "amd64", t.TempDir()); err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	if got := srv.archiveRequests(); len(got) != 1 {
+		t.Fatalf("archive requests = %d, want 1", len(got))
+	}
+}
+
+func TestGitHubReleasesManifest(t *testing.T) {
+	archive := tarGz(t, "baton", binaryContent)
+	sum := sha256.Sum256(archive)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/repos/ConductorOne/baton/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprintf(w, `{"tag_name": "v0.2.4", "assets": [
+			{"name": "baton-v0.2.4-linux-amd64.tar.gz", "size": %d, "browser_download_url": %q},
+			{"name": "baton-v0.2.4-linux-amd64.tar.gz.sbom.json", "size": 2, "browser_download_url": %q},
+			{"name": "baton_0.2.4_checksums.txt", "size": 1, "browser_download_url": %q}
+		]}`, len(archive), srv.URL+"/download/archive", srv.URL+"/download/sbom", srv.URL+"/download/checksums")
+	})
+	mux.HandleFunc("/download/checksums", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  baton-v0.2.4-linux-amd64.tar.gz\n", hex.EncodeToString(sum[:]))
+	})
+	mux.HandleFunc("/download/archive", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	g := &GitHubReleases{APIURL: srv.URL, Token: "t", HTTPClient: srv.Client()}
+	m, err := g.Manifest(context.Background(), "ConductorOne", "baton", Stable)
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	if m.GetSemver() != "v0.2.4" || len(m.GetAssets()) != 1 {
+		t.Fatalf("manifest = %v", m)
+	}
+	_, asset, err := SelectAsset(m, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{HTTPClient: srv.Client(), CacheDir: t.TempDir()}
+	if _, err := c.Download(context.Background(), asset); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	// A tag whose release does not exist is an error.
+	if _, err := g.Manifest(context.Background(), "ConductorOne", "baton", "v9.9.9"); err == nil {
+		t.Fatal("expected an error for a missing release")
+	}
+}
+
+func TestDownloadResumesPartialFile(t *testing.T) {
+	srv := newDistServer(t, "")
+	cacheDir := t.TempDir()
+	c := &Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: cacheDir}
+
+	m, err := c.Manifest(context.Background(), "ConductorOne", "baton-example", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	_, asset, err := SelectAsset(m, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial := filepath.Join(cacheDir, asset.GetSha256(), asset.GetFilename()+".partial")
+	if err := os.MkdirAll(filepath.Dir(partial), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partial, srv.archive[:10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := c.Download(context.Background(), asset)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if got := srv.archiveRequests(); len(got) != 1 || got[0] != "bytes=10-" {
+		t.Fatalf("archive requests = %q, want one with bytes=10-", got)
+	}
+	if _, err := os.Stat(partial); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("partial file still present: %v", err)
+	}
+	if sum, err := manifest.SHA256File(path); err != nil || sum != asset.GetSha256() {
+		t.Fatalf("cached sha256 = %s, %v", sum, err)
+	}
+}
+
+func TestDownloadRejectsChecksumMismatch(t *testing.T) {
+	srv := newDistServer(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	cacheDir := t.TempDir()
+	c := &Client{BaseURL: srv.URL, HTTPClient: srv.Client(), CacheDir: cacheDir}
+
+	_, err := c.Fetch(context.Background(), "ConductorOne", "baton-example", "v1.0.0", "linux", "amd64", t.TempDir())
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(cacheDir, "*", "*"))
+	if len(matches) != 0 {
+		t.Fatalf("cache contains %v after a failed download", matches)
+	}
+}
+
+func TestSelectAssetReportsAvailablePlatforms(t *testing.T) {
+	m := pb.Manifest_builder{Assets: map[string]*pb.Asset{
+		"linux-amd64":       {},
+		"windows-amd64-msi": {},
+	}}.Build()
+	if _, _, err := SelectAsset(m, "darwin", "arm64"); !errors.Is(err, ErrNoAsset) {
+		t.Fatalf("err = %v, want ErrNoAsset", err)
+	}
+	if _, _, err := SelectAsset(m, "windows", "amd64"); !errors.Is(err, ErrNoAsset) {
+		t.Fatalf("err = %v, want ErrNoAsset for msi-only windows", err)
+	}
+}
+
+func TestUnpackRejectsPathTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../evil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("x"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "evil.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := Unpack(path, dest); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("err = %v, want ErrUnsafePath", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("entry was written outside the destination")
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
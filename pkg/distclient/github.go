@@ -0,0 +1,153 @@
+package distclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ConductorOne/github-workflows/internal/manifest"
+	pb "github.com/ConductorOne/github-workflows/pb/artifacts/v1"
+)
+
+// DefaultGitHubAPIURL is the GitHub REST API used by GitHubReleases.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubReleases reads releases from the GitHub releases API, for projects
+// that are not published to the dist bucket. Each release is described as a
+// manifest whose archive assets are keyed "{goos}-{goarch}" from their
+// filenames and carry the sha256 listed in the release's checksums file, so
+// Client.Download verifies them as it does dist assets. The manifests have no
+// attestations.
+type GitHubReleases struct {
+	// APIURL is the REST API root; DefaultGitHubAPIURL when empty.
+	APIURL string
+	// Token, when set, is sent as a bearer token to raise the API rate limit.
+	Token string
+	// HTTPClient sends every request; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Manifest returns the release for version, which is either Stable (the
+// latest GitHub release) or a tag.
+func (g *GitHubReleases) Manifest(ctx context.Context, org, repo, version string) (*pb.Manifest, error) {
+	path := "/repos/" + org + "/" + repo + "/releases/tags/" + version
+	if version == Stable {
+		path = "/repos/" + org + "/" + repo + "/releases/latest"
+	}
+	data, err := g.get(ctx, strings.TrimSuffix(g.apiURL(), "/")+path, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	var release githubRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("parsing %s/%s release %s: %w", org, repo, version, err)
+	}
+
+	var checksums map[string]string
+	for _, a := range release.Assets {
+		if strings.HasSuffix(a.Name, "checksums.txt") {
+			data, err := g.get(ctx, a.BrowserDownloadURL, "application/octet-stream")
+			if err != nil {
+				return nil, err
+			}
+			if checksums, err = manifest.ParseChecksums(bytes.NewReader(data)); err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", a.Name, err)
+			}
+			break
+		}
+	}
+	if checksums == nil {
+		return nil, fmt.Errorf("%s/%s release %s has no checksums.txt asset", org, repo, release.TagName)
+	}
+
+	assets := make(map[string]*pb.Asset)
+	for _, a := range release.Assets {
+		key, ok := platformKey(a.Name)
+		if !ok {
+			continue
+		}
+		sha, ok := checksums[a.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not listed in the checksums of %s/%s release %s", a.Name, org, repo, release.TagName)
+		}
+		assets[key] = pb.Asset_builder{
+			Filename:  &a.Name,
+			SizeBytes: &a.Size,
+			Sha256:    &sha,
+			Href:      &a.BrowserDownloadURL,
+		}.Build()
+	}
+	return pb.Manifest_builder{
+		Org:    &org,
+		Name:   &repo,
+		Semver: &release.TagName,
+		Assets: assets,
+	}.Build(), nil
+}
+
+// platformKey returns "{goos}-{goarch}" for an archive named
+// {name}-{version}-{goos}-{goarch}.tar.gz, .tgz or .zip.
+func platformKey(filename string) (string, bool) {
+	if !manifest.IsArchive(filename) {
+		return "", false
+	}
+	base := filename
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	parts := strings.Split(base, "-")
+	if len(parts) < 3 {
+		return "", false
+	}
+	return parts[len(parts)-2] + "-" + parts[len(parts)-1], true
+}
+
+func (g *GitHubReleases) get(ctx context.Context, href, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", href, err)
+	}
+	req.Header.Set("Accept", accept)
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	client := g.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", href, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", href, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", href, err)
+	}
+	return data, nil
+}
+
+func (g *GitHubReleases) apiURL() string {
+	if g.APIURL == "" {
+		return DefaultGitHubAPIURL
+	}
+	return g.APIURL
+}
@@ -0,0 +1,133 @@
+package distclient
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafePath is returned when an archive entry would be written outside the destination.
+var ErrUnsafePath = errors.New("archive entry escapes destination")
+
+// Unpack extracts a .zip, .tar.gz or .tgz archive into dir, creating dir if
+// needed. Regular files and directories are extracted; links and other entry
+// types are skipped.
+func Unpack(archivePath, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	name := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return unpackZip(archivePath, dir)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return unpackTarGz(archivePath, dir)
+	default:
+		return fmt.Errorf("unpacking %s: unsupported archive format", filepath.Base(archivePath))
+	}
+}
+
+func unpackZip(archivePath, dir string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", archivePath, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		switch mode := f.Mode(); {
+		case mode.IsDir():
+			if err := mkdirEntry(dir, f.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", f.Name, err)
+			}
+			err = writeEntry(dir, f.Name, mode, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func unpackTarGz(archivePath, dir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", archivePath, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivePath, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := mkdirEntry(dir, hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(dir, hdr.Name, hdr.FileInfo().Mode(), tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// entryPath resolves an archive entry name inside dir, rejecting absolute
+// paths and "..".
+func entryPath(dir, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+func mkdirEntry(dir, name string) error {
+	path, err := entryPath(dir, name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, 0o755)
+}
+
+func writeEntry(dir, name string, mode fs.FileMode, r io.Reader) error {
+	path, err := entryPath(dir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("extracting %s: %w", name, err)
+	}
+	return out.Close()
+}